@@ -0,0 +1,167 @@
+// Package audit provides sinks for the authorization decisions reported by permission.OnDecision.
+//
+// Sinks can be wrapped to sample decisions or redact subject identifiers:
+//
+//	w := audit.NewJSONWriter(file)
+//	permission.OnDecision(audit.Sample(audit.Redact(w, audit.HashSubject(key)), 0.01, 1))
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/asdine/permission"
+)
+
+// Entry is the JSON representation of a decision
+type Entry struct {
+	Time     time.Time         `json:"time"`
+	Subject  string            `json:"subject,omitempty"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Required []string          `json:"required"`
+	Scope    []string          `json:"scope"`
+	Allowed  bool              `json:"allowed"`
+	Reason   permission.Reason `json:"reason"`
+	Match    string            `json:"match,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Duration time.Duration     `json:"durationNs"`
+}
+
+// NewEntry returns the JSON representation of the decision
+func NewEntry(d permission.Decision) Entry {
+	e := Entry{
+		Time:     d.Time,
+		Subject:  d.Subject,
+		Tenant:   d.Tenant,
+		Required: toStrings(d.Required),
+		Scope:    toStrings(d.Scope),
+		Allowed:  d.Allowed,
+		Reason:   d.Reason,
+		Duration: d.Duration,
+	}
+
+	if !d.Match.IsZero() {
+		e.Match = d.Match.String()
+	}
+
+	if d.Err != nil {
+		e.Error = d.Err.Error()
+	}
+
+	return e
+}
+
+func toStrings(s permission.Scope) []string {
+	list := make([]string, len(s))
+	for i, p := range s {
+		list[i] = p.String()
+	}
+	return list
+}
+
+// JSONWriter writes decisions as JSON lines.
+// It is safe for concurrent use
+type JSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONWriter returns a JSONWriter that writes to w
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{enc: json.NewEncoder(w)}
+}
+
+// HandleDecision writes the decision as a JSON line
+func (w *JSONWriter) HandleDecision(ctx context.Context, d permission.Decision) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.enc.Encode(NewEntry(d))
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// Err returns the first error encountered while writing
+func (w *JSONWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Sampler forwards a fraction of the decisions to the next hook
+type Sampler struct {
+	Next permission.DecisionHook
+
+	// AllowedRate and DeniedRate are the fractions, between 0 and 1,
+	// of the allowed and denied decisions that are forwarded
+	AllowedRate float64
+	DeniedRate  float64
+
+	// Random returns a number in [0, 1). Defaults to rand.Float64
+	Random func() float64
+}
+
+// Sample returns a Sampler forwarding the given fractions of allowed and denied decisions
+func Sample(next permission.DecisionHook, allowedRate, deniedRate float64) *Sampler {
+	return &Sampler{Next: next, AllowedRate: allowedRate, DeniedRate: deniedRate}
+}
+
+// HandleDecision forwards the decision if it is sampled
+func (s *Sampler) HandleDecision(ctx context.Context, d permission.Decision) {
+	rate := s.DeniedRate
+	if d.Allowed {
+		rate = s.AllowedRate
+	}
+
+	if rate <= 0 {
+		return
+	}
+
+	if rate < 1 {
+		random := s.Random
+		if random == nil {
+			random = rand.Float64
+		}
+
+		if random() >= rate {
+			return
+		}
+	}
+
+	s.Next.HandleDecision(ctx, d)
+}
+
+// Redact returns a hook replacing the subject of the decisions using fn
+// before forwarding them to the next hook
+func Redact(next permission.DecisionHook, fn func(subject string) string) permission.DecisionHook {
+	return permission.DecisionHookFunc(func(ctx context.Context, d permission.Decision) {
+		if d.Subject != "" {
+			d.Subject = fn(d.Subject)
+		}
+		next.HandleDecision(ctx, d)
+	})
+}
+
+// HashSubject returns a redaction function that replaces subjects by their keyed hash.
+// The same subject always gets the same hash so that decisions can still be correlated
+func HashSubject(key []byte) func(subject string) string {
+	return func(subject string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(subject))
+		return hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+}
+
+// DropSubject is a redaction function that removes subjects
+func DropSubject(subject string) string {
+	return ""
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	allowed = permission.Decision{
+		Time:     time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC),
+		Subject:  "alice",
+		Required: permission.Scope{{Name: "user", Sub: "edit"}},
+		Scope:    permission.Scope{{Name: "user", Sub: "edit"}, {Name: "playlist"}},
+		Allowed:  true,
+		Reason:   permission.ReasonGranted,
+		Match:    permission.Permission{Name: "user", Sub: "edit"},
+		Duration: 1500,
+	}
+
+	denied = permission.Decision{
+		Time:    time.Date(2016, 1, 1, 12, 0, 1, 0, time.UTC),
+		Subject: "bob",
+		Tenant:  "acme",
+		Reason:  permission.ReasonError,
+		Err:     errors.New("boom"),
+	}
+)
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+
+	w.HandleDecision(context.Background(), allowed)
+	w.HandleDecision(context.Background(), denied)
+	require.NoError(t, w.Err())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"time":"2016-01-01T12:00:00Z","subject":"alice","required":["user.edit"],"scope":["user.edit","playlist"],"allowed":true,"reason":"granted","match":"user.edit","durationNs":1500}`, lines[0])
+	assert.JSONEq(t, `{"time":"2016-01-01T12:00:01Z","subject":"bob","tenant":"acme","required":[],"scope":[],"allowed":false,"reason":"error","error":"boom","durationNs":0}`, lines[1])
+
+	var e Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, NewEntry(allowed), e)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestJSONWriterError(t *testing.T) {
+	w := NewJSONWriter(failingWriter{})
+	w.HandleDecision(context.Background(), allowed)
+	assert.EqualError(t, w.Err(), "disk full")
+}
+
+func TestSampler(t *testing.T) {
+	r := NewRing(10)
+	s := Sample(r, 0.25, 1)
+
+	random := []float64{0.1, 0.5, 0.2, 0.9}
+	s.Random = func() float64 {
+		v := random[0]
+		random = random[1:]
+		return v
+	}
+
+	for i := 0; i < 4; i++ {
+		s.HandleDecision(context.Background(), allowed)
+	}
+	s.HandleDecision(context.Background(), denied)
+	assert.Len(t, random, 0)
+	assert.Equal(t, 3, r.Len())
+
+	r.Reset()
+	s = Sample(r, 0, 0)
+	s.HandleDecision(context.Background(), allowed)
+	s.HandleDecision(context.Background(), denied)
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestRedact(t *testing.T) {
+	r := NewRing(10)
+	hash := HashSubject([]byte("key"))
+
+	h := Redact(r, hash)
+	h.HandleDecision(context.Background(), allowed)
+	h.HandleDecision(context.Background(), allowed)
+	h.HandleDecision(context.Background(), permission.Decision{})
+
+	decisions := r.Decisions()
+	assert.Len(t, decisions[0].Subject, 16)
+	assert.NotEqual(t, "alice", decisions[0].Subject)
+	assert.Equal(t, decisions[0].Subject, decisions[1].Subject)
+	assert.NotEqual(t, hash("alice"), HashSubject([]byte("other"))("alice"))
+	assert.Empty(t, decisions[2].Subject)
+	assert.Equal(t, "alice", allowed.Subject)
+
+	r.Reset()
+	Redact(r, DropSubject).HandleDecision(context.Background(), allowed)
+	assert.Empty(t, r.Decisions()[0].Subject)
+}
+
+func TestHooks(t *testing.T) {
+	r := NewRing(10)
+	permission.OnDecision(Redact(r, DropSubject))
+	defer permission.OnDecision()
+
+	def := permission.Definitions{{Name: "user", Subset: []string{"edit"}}}
+	def.Decide(context.Background(), "alice", permission.Scope{{Name: "user", Sub: "edit"}}, nil)
+
+	require.Equal(t, 1, r.Len())
+	d := r.Decisions()[0]
+	assert.False(t, d.Allowed)
+	assert.Equal(t, permission.ReasonNotGranted, d.Reason)
+	assert.Empty(t, d.Subject)
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/asdine/permission"
+)
+
+// Ring keeps the last decisions in memory, which is useful in tests.
+// It is safe for concurrent use
+type Ring struct {
+	mu   sync.Mutex
+	buf  []permission.Decision
+	next int
+	full bool
+}
+
+// NewRing returns a Ring that keeps the last size decisions
+func NewRing(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Ring{buf: make([]permission.Decision, size)}
+}
+
+// HandleDecision stores a copy of the decision, replacing the oldest one if the ring is full
+func (r *Ring) HandleDecision(ctx context.Context, d permission.Decision) {
+	d.Required = append(permission.Scope(nil), d.Required...)
+	d.Scope = append(permission.Scope(nil), d.Scope...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf[r.next] = d
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Decisions returns the stored decisions, oldest first
+func (r *Ring) Decisions() []permission.Decision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]permission.Decision(nil), r.buf[:r.next]...)
+	}
+
+	return append(append([]permission.Decision(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+// Len returns the number of stored decisions
+func (r *Ring) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.full {
+		return len(r.buf)
+	}
+	return r.next
+}
+
+// Reset removes the stored decisions
+func (r *Ring) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.buf {
+		r.buf[i] = permission.Decision{}
+	}
+	r.next = 0
+	r.full = false
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing(3)
+	assert.Len(t, r.Decisions(), 0)
+
+	for _, subject := range []string{"a", "b"} {
+		r.HandleDecision(context.Background(), permission.Decision{Subject: subject})
+	}
+	assert.Equal(t, 2, r.Len())
+	assert.Equal(t, []string{"a", "b"}, subjects(r.Decisions()))
+
+	for _, subject := range []string{"c", "d", "e"} {
+		r.HandleDecision(context.Background(), permission.Decision{Subject: subject})
+	}
+	assert.Equal(t, 3, r.Len())
+	assert.Equal(t, []string{"c", "d", "e"}, subjects(r.Decisions()))
+
+	// stored decisions don't share the scopes of the caller
+	s := permission.Scope{{Name: "user"}}
+	r.HandleDecision(context.Background(), permission.Decision{Scope: s})
+	s[0].Name = "admin"
+	assert.Equal(t, "user", r.Decisions()[2].Scope[0].Name)
+
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+	assert.Len(t, r.Decisions(), 0)
+
+	assert.Equal(t, 1, len(NewRing(0).buf))
+}
+
+func subjects(decisions []permission.Decision) []string {
+	var list []string
+	for _, d := range decisions {
+		list = append(list, d.Subject)
+	}
+	return list
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/asdine/permission"
+)
+
+// SlogLogger logs decisions with a slog.Logger
+type SlogLogger struct {
+	logger *slog.Logger
+
+	// AllowedLevel and DeniedLevel are the levels of the allowed and denied decisions.
+	// They default to Info and Warn
+	AllowedLevel slog.Level
+	DeniedLevel  slog.Level
+}
+
+// NewSlogLogger returns a SlogLogger that logs with the logger
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{
+		logger:       logger,
+		AllowedLevel: slog.LevelInfo,
+		DeniedLevel:  slog.LevelWarn,
+	}
+}
+
+// HandleDecision logs the decision
+func (l *SlogLogger) HandleDecision(ctx context.Context, d permission.Decision) {
+	level := l.DeniedLevel
+	if d.Allowed {
+		level = l.AllowedLevel
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	e := NewEntry(d)
+	attrs := []slog.Attr{
+		slog.Bool("allowed", e.Allowed),
+		slog.String("reason", string(e.Reason)),
+		slog.Any("required", e.Required),
+		slog.Any("scope", e.Scope),
+		slog.Duration("duration", e.Duration),
+	}
+
+	if e.Subject != "" {
+		attrs = append(attrs, slog.String("subject", e.Subject))
+	}
+	if e.Tenant != "" {
+		attrs = append(attrs, slog.String("tenant", e.Tenant))
+	}
+	if e.Match != "" {
+		attrs = append(attrs, slog.String("match", e.Match))
+	}
+	if e.Error != "" {
+		attrs = append(attrs, slog.String("error", e.Error))
+	}
+
+	l.logger.LogAttrs(ctx, level, "permission decision", attrs...)
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	l := NewSlogLogger(logger)
+	l.HandleDecision(context.Background(), allowed)
+	l.HandleDecision(context.Background(), denied)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `level=INFO msg="permission decision" allowed=true reason=granted required=[user.edit] scope="[user.edit playlist]" duration=1.5µs subject=alice match=user.edit`, lines[0])
+	assert.Equal(t, `level=WARN msg="permission decision" allowed=false reason=error required=[] scope=[] duration=0s subject=bob tenant=acme error=boom`, lines[1])
+
+	buf.Reset()
+	l.AllowedLevel = slog.LevelDebug
+	l.HandleDecision(context.Background(), allowed)
+	assert.Empty(t, buf.String())
+}
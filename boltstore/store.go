@@ -0,0 +1,404 @@
+// Package boltstore provides a permission.Store backed by a bbolt database.
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/asdine/permission"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names.
+// Grants and role assignments are stored twice: by subject,
+// and in indexes by permission and by role
+var (
+	grantsBucket      = []byte("grants")
+	permissionsBucket = []byte("permissions")
+	rolesBucket       = []byte("roles")
+	membersBucket     = []byte("members")
+)
+
+// New returns a Store using the given database, creating the buckets if needed
+func New(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{grantsBucket, permissionsBucket, rolesBucket, membersBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Store is a permission.Store backed by a bbolt database
+type Store struct {
+	db *bolt.DB
+}
+
+// Grant implements the permission.StoreTx interface
+func (s *Store) Grant(ctx context.Context, subject string, scope permission.Scope) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Grant(ctx, subject, scope)
+	})
+}
+
+// Revoke implements the permission.StoreTx interface
+func (s *Store) Revoke(ctx context.Context, subject string, scope permission.Scope) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Revoke(ctx, subject, scope)
+	})
+}
+
+// Assign implements the permission.StoreTx interface
+func (s *Store) Assign(ctx context.Context, subject string, roles ...string) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Assign(ctx, subject, roles...)
+	})
+}
+
+// Unassign implements the permission.StoreTx interface
+func (s *Store) Unassign(ctx context.Context, subject string, roles ...string) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Unassign(ctx, subject, roles...)
+	})
+}
+
+// ScopeOf implements the permission.StoreReader interface
+func (s *Store) ScopeOf(ctx context.Context, subject string) (scope permission.Scope, err error) {
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		scope, err = r.ScopeOf(ctx, subject)
+		return err
+	})
+	return
+}
+
+// SubjectsWith implements the permission.StoreReader interface
+func (s *Store) SubjectsWith(ctx context.Context, p permission.Permission) (subjects []string, err error) {
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		subjects, err = r.SubjectsWith(ctx, p)
+		return err
+	})
+	return
+}
+
+// SubjectsWithPrefix implements the permission.PrefixReader interface
+func (s *Store) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) (subjects []string, err error) {
+	err = s.db.View(func(t *bolt.Tx) error {
+		subjects, err = (&tx{t}).SubjectsWithPrefix(ctx, p, prefix)
+		return err
+	})
+	return
+}
+
+// RolesOf implements the permission.StoreReader interface
+func (s *Store) RolesOf(ctx context.Context, subject string) (roles []string, err error) {
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		roles, err = r.RolesOf(ctx, subject)
+		return err
+	})
+	return
+}
+
+// MembersOf implements the permission.StoreReader interface
+func (s *Store) MembersOf(ctx context.Context, role string) (subjects []string, err error) {
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		subjects, err = r.MembersOf(ctx, role)
+		return err
+	})
+	return
+}
+
+// Subjects implements the permission.StoreReader interface
+func (s *Store) Subjects(ctx context.Context) (subjects []string, err error) {
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		subjects, err = r.Subjects(ctx)
+		return err
+	})
+	return
+}
+
+// SubjectsWithName returns the sorted subjects granted any permission with the given name,
+// using the permission index
+func (s *Store) SubjectsWithName(ctx context.Context, name string) (subjects []string, err error) {
+	if name == "" {
+		return nil, permission.ErrEmptyName
+	}
+	if strings.IndexByte(name, 0) >= 0 {
+		return nil, permission.ErrBadFormat
+	}
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		seen := make(map[string]bool)
+		prefix := append([]byte(name), 0)
+		c := tx.Bucket(permissionsBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			for _, subject := range keys(tx.Bucket(permissionsBucket).Bucket(k)) {
+				if !seen[subject] {
+					seen[subject] = true
+					subjects = append(subjects, subject)
+				}
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(subjects)
+	return
+}
+
+// Update implements the permission.Store interface
+func (s *Store) Update(ctx context.Context, fn func(tx permission.StoreTx) error) error {
+	return s.db.Update(func(t *bolt.Tx) error {
+		return fn(&tx{t})
+	})
+}
+
+// View implements the permission.Store interface
+func (s *Store) View(ctx context.Context, fn func(r permission.StoreReader) error) error {
+	return s.db.View(func(t *bolt.Tx) error {
+		return fn(&tx{t})
+	})
+}
+
+type tx struct {
+	tx *bolt.Tx
+}
+
+func (t *tx) Grant(ctx context.Context, subject string, scope permission.Scope) error {
+	err := validateGrant(subject, scope)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range scope {
+		err = link(t.tx, grantsBucket, []byte(subject), permissionKey(p), permissionsBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Revoke(ctx context.Context, subject string, scope permission.Scope) error {
+	err := validateGrant(subject, scope)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range scope {
+		err = unlink(t.tx, grantsBucket, []byte(subject), permissionKey(p), permissionsBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Assign(ctx context.Context, subject string, roles ...string) error {
+	err := permission.ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		err = link(t.tx, rolesBucket, []byte(subject), []byte(role), membersBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Unassign(ctx context.Context, subject string, roles ...string) error {
+	err := permission.ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		err = unlink(t.tx, rolesBucket, []byte(subject), []byte(role), membersBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) ScopeOf(ctx context.Context, subject string) (permission.Scope, error) {
+	if subject == "" {
+		return nil, permission.ErrEmptySubject
+	}
+
+	var scope permission.Scope
+	for _, k := range keys(t.tx.Bucket(grantsBucket).Bucket([]byte(subject))) {
+		scope = append(scope, parsePermissionKey(k))
+	}
+
+	return scope, nil
+}
+
+func (t *tx) SubjectsWith(ctx context.Context, p permission.Permission) ([]string, error) {
+	if p.Name == "" {
+		return nil, permission.ErrEmptyName
+	}
+	if strings.IndexByte(p.Name, 0) >= 0 {
+		return nil, permission.ErrBadFormat
+	}
+
+	return keys(t.tx.Bucket(permissionsBucket).Bucket(permissionKey(p))), nil
+}
+
+func (t *tx) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	if p.Name == "" {
+		return nil, permission.ErrEmptyName
+	}
+	if strings.IndexByte(p.Name, 0) >= 0 {
+		return nil, permission.ErrBadFormat
+	}
+
+	b := t.tx.Bucket(permissionsBucket).Bucket(permissionKey(p))
+	if b == nil {
+		return nil, nil
+	}
+
+	var subjects []string
+	c := b.Cursor()
+	for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+		subjects = append(subjects, string(k))
+	}
+	return subjects, nil
+}
+
+func (t *tx) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	if subject == "" {
+		return nil, permission.ErrEmptySubject
+	}
+
+	return keys(t.tx.Bucket(rolesBucket).Bucket([]byte(subject))), nil
+}
+
+func (t *tx) MembersOf(ctx context.Context, role string) ([]string, error) {
+	if role == "" {
+		return nil, permission.ErrEmptyRole
+	}
+
+	return keys(t.tx.Bucket(membersBucket).Bucket([]byte(role))), nil
+}
+
+func (t *tx) Subjects(ctx context.Context) ([]string, error) {
+	subjects := keys(t.tx.Bucket(grantsBucket))
+	for _, subject := range keys(t.tx.Bucket(rolesBucket)) {
+		if t.tx.Bucket(grantsBucket).Bucket([]byte(subject)) == nil {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+// validateGrant validates the grant like permission.ValidateGrant.
+// Returns ErrBadFormat if a name contains a NUL byte, which separates the name from the sub in the keys
+func validateGrant(subject string, scope permission.Scope) error {
+	err := permission.ValidateGrant(subject, scope)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range scope {
+		if strings.IndexByte(p.Name, 0) >= 0 {
+			return permission.ErrBadFormat
+		}
+	}
+	return nil
+}
+
+// permissionKey encodes the permission independently of the global delimiter.
+// The name can't contain a NUL byte, the sub can.
+// Keys sharing the same name are contiguous
+func permissionKey(p permission.Permission) []byte {
+	k := make([]byte, 0, len(p.Name)+len(p.Sub)+1)
+	k = append(k, p.Name...)
+	k = append(k, 0)
+	return append(k, p.Sub...)
+}
+
+func parsePermissionKey(k string) permission.Permission {
+	i := strings.IndexByte(k, 0)
+	return permission.Permission{Name: k[:i], Sub: k[i+1:]}
+}
+
+// link adds value to the key bucket of the primary bucket, and key to the value bucket of the index
+func link(tx *bolt.Tx, primary, key, value, index []byte) error {
+	b, err := tx.Bucket(primary).CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+
+	err = b.Put(value, nil)
+	if err != nil {
+		return err
+	}
+
+	b, err = tx.Bucket(index).CreateBucketIfNotExists(value)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(key, nil)
+}
+
+// unlink reverts link, removing the buckets left empty
+func unlink(tx *bolt.Tx, primary, key, value, index []byte) error {
+	err := remove(tx.Bucket(primary), key, value)
+	if err != nil {
+		return err
+	}
+
+	return remove(tx.Bucket(index), value, key)
+}
+
+func remove(parent *bolt.Bucket, name, key []byte) error {
+	b := parent.Bucket(name)
+	if b == nil {
+		return nil
+	}
+
+	err := b.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	if k, _ := b.Cursor().First(); k == nil {
+		return parent.DeleteBucket(name)
+	}
+
+	return nil
+}
+
+// keys returns the sorted keys of the bucket
+func keys(b *bolt.Bucket) []string {
+	if b == nil {
+		return nil
+	}
+
+	var list []string
+	b.ForEach(func(k, v []byte) error {
+		list = append(list, string(k))
+		return nil
+	})
+	return list
+}
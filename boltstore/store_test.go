@@ -0,0 +1,94 @@
+package boltstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openStore(t *testing.T) *Store {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "perms.db"), 0600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := New(db)
+	require.NoError(t, err)
+	return s
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		return openStore(t)
+	})
+}
+
+func TestSubjectsWithName(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t)
+
+	require.NoError(t, s.Grant(ctx, "carol", permission.Scope{{Name: "user", Sub: "edit"}}))
+	require.NoError(t, s.Grant(ctx, "alice", permission.Scope{{Name: "user"}, {Name: "user", Sub: "edit"}}))
+	require.NoError(t, s.Grant(ctx, "bob", permission.Scope{{Name: "users"}, {Name: "playlist"}}))
+
+	subjects, err := s.SubjectsWithName(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol"}, subjects)
+
+	subjects, err = s.SubjectsWithName(ctx, "friends")
+	require.NoError(t, err)
+	assert.Len(t, subjects, 0)
+
+	_, err = s.SubjectsWithName(ctx, "")
+	assert.Equal(t, permission.ErrEmptyName, err)
+}
+
+func TestNulInName(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t)
+
+	// the sub can contain NUL, the name can't as it would be decoded as another permission
+	sub := permission.Permission{Name: "user", Sub: "b\x00c"}
+	require.NoError(t, s.Grant(ctx, "alice", permission.Scope{sub}))
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, permission.Scope{sub}, granted)
+
+	name := permission.Permission{Name: "user\x00b", Sub: "c"}
+	assert.Equal(t, permission.ErrBadFormat, s.Grant(ctx, "bob", permission.Scope{name}))
+	assert.Equal(t, permission.ErrBadFormat, s.Revoke(ctx, "alice", permission.Scope{name}))
+
+	_, err = s.SubjectsWith(ctx, name)
+	assert.Equal(t, permission.ErrBadFormat, err)
+	_, err = s.SubjectsWithPrefix(ctx, name, "")
+	assert.Equal(t, permission.ErrBadFormat, err)
+	_, err = s.SubjectsWithName(ctx, "user\x00b")
+	assert.Equal(t, permission.ErrBadFormat, err)
+
+	granted, err = s.ScopeOf(ctx, "bob")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+}
+
+func TestIndexCleanup(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t)
+
+	require.NoError(t, s.Grant(ctx, "alice", permission.Scope{{Name: "user", Sub: "edit"}}))
+	require.NoError(t, s.Assign(ctx, "alice", "admin"))
+	require.NoError(t, s.Revoke(ctx, "alice", permission.Scope{{Name: "user", Sub: "edit"}}))
+	require.NoError(t, s.Unassign(ctx, "alice", "admin"))
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{grantsBucket, permissionsBucket, rolesBucket, membersBucket} {
+			assert.Len(t, keys(tx.Bucket(name)), 0, string(name))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
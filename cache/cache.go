@@ -0,0 +1,354 @@
+// Package cache caches the decisions of evaluations that resolve roles, expand groups or read a store.
+//
+// Decisions are kept in an LRU with an optional TTL, keyed by the tenant, the subject,
+// the normalized presented scope and required permissions or the version of the roles
+// the scope is resolved with, and the version of the definitions.
+// Changing the definitions with SetDefinitions makes every cached decision obsolete,
+// and the Store returned by NewStore invalidates the decisions of the subjects whose grants
+// or roles change. A Cache is safe for concurrent use.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/asdine/permission"
+)
+
+// DefaultSize is the number of decisions kept when Options.Size is zero
+const DefaultSize = 1024
+
+// tenantSeparator separates the tenant from the subject, like in the keys of a store partitioned by tenant
+const tenantSeparator = "/"
+
+// Options of a Cache
+type Options struct {
+	// Size is the maximum number of decisions, the least recently used are evicted first
+	Size int
+
+	// TTL is the duration a decision is kept. The zero value keeps decisions until they are evicted
+	TTL time.Duration
+
+	// Clock used to expire the decisions. Defaults to permission.SystemClock
+	Clock permission.Clock
+}
+
+// Key identifies a decision
+type Key struct {
+	// Tenant is empty if the evaluation is not partitioned by tenant
+	Tenant  string
+	Subject string
+
+	// Scope presented by the subject and Required permissions, normalized.
+	// Scope is empty if the evaluation reads the scope from a store
+	Scope    string
+	Required string
+
+	// Stored is true if the scope of the subject is read from a store instead of being presented
+	Stored bool
+
+	// Roles is the version of the roles the stored scope is resolved with, see RolesVersion
+	Roles string
+}
+
+// NewKey returns the key of a decision, normalizing the scopes
+// so that the order and the duplicates of the permissions don't matter
+func NewKey(tenant, subject string, required, scope permission.Scope) Key {
+	return Key{Tenant: tenant, Subject: subject, Scope: normalize(scope), Required: normalize(required)}
+}
+
+func normalize(s permission.Scope) string {
+	raw, _ := s.Normalize().MarshalText()
+	return string(raw)
+}
+
+// owner returns the subject of the key as it is stored in a store partitioned by tenant
+func (k Key) owner() string {
+	if k.Tenant == "" {
+		return k.Subject
+	}
+	return k.Tenant + tenantSeparator + k.Subject
+}
+
+type versionedKey struct {
+	Key
+	version string
+}
+
+type entry struct {
+	key      versionedKey
+	decision permission.Decision
+	expires  time.Time
+}
+
+// Stats of a Cache
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// HitRate returns the ratio of lookups that were hits, 0 without lookups
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache of decisions
+type Cache struct {
+	size  int
+	ttl   time.Duration
+	clock permission.Clock
+
+	mu      sync.Mutex
+	defs    permission.Definitions
+	version string
+	lru     *list.List
+	entries map[versionedKey]*list.Element
+	owners  map[string]map[versionedKey]struct{}
+	stats   Stats
+
+	// generation changes on every invalidation so that decisions
+	// computed before are not stored after
+	generation uint64
+}
+
+// New returns a Cache of the decisions made with the definitions
+func New(defs permission.Definitions, opts Options) *Cache {
+	c := Cache{
+		size:  opts.Size,
+		ttl:   opts.TTL,
+		clock: opts.Clock,
+	}
+	if c.size <= 0 {
+		c.size = DefaultSize
+	}
+	if c.clock == nil {
+		c.clock = permission.SystemClock
+	}
+
+	c.Purge()
+	c.defs, c.version = defs, Version(defs)
+	return &c
+}
+
+// Version returns a fingerprint of the definitions
+func Version(defs permission.Definitions) string {
+	return fingerprint(defs)
+}
+
+// RolesVersion returns a fingerprint of the roles
+func RolesVersion(roles permission.Roles) string {
+	return fingerprint(roles)
+}
+
+func fingerprint(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Definitions returns the definitions used to make the decisions
+func (c *Cache) Definitions() permission.Definitions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.defs
+}
+
+// Version returns the version of the definitions used to make the decisions
+func (c *Cache) Version() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// SetDefinitions replaces the definitions. The decisions made with other definitions are removed
+func (c *Cache) SetDefinitions(defs permission.Definitions) {
+	version := Version(defs)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.defs = defs
+	if version != c.version {
+		c.version = version
+		c.purge()
+	}
+}
+
+// Do returns the cached decision of the key or calls fn and caches its result.
+// The version of the definitions is added to the key. Errors are not cached
+func (c *Cache) Do(key Key, fn func(defs permission.Definitions) (permission.Decision, error)) (permission.Decision, error) {
+	d, _, err := c.do(key, fn)
+	return d, err
+}
+
+// do is like Do and reports wether the decision was cached
+func (c *Cache) do(key Key, fn func(defs permission.Definitions) (permission.Decision, error)) (permission.Decision, bool, error) {
+	c.mu.Lock()
+	vk := versionedKey{Key: key, version: c.version}
+	if d, ok := c.get(vk); ok {
+		c.mu.Unlock()
+		return d, true, nil
+	}
+	defs, generation := c.defs, c.generation
+	c.mu.Unlock()
+
+	d, err := fn(defs)
+	if err != nil {
+		return d, false, err
+	}
+
+	c.mu.Lock()
+	if generation == c.generation {
+		c.add(vk, d)
+	}
+	c.mu.Unlock()
+	return d, false, nil
+}
+
+// decide returns the cached decision of the key or calls fn.
+// The decision hooks are notified of cached decisions as well, with their time and duration updated
+func (c *Cache) decide(ctx context.Context, key Key, fn func(defs permission.Definitions) (permission.Decision, error)) (permission.Decision, error) {
+	start := time.Now()
+	d, hit, err := c.do(key, fn)
+	if hit {
+		d.Time = start
+		d.Duration = time.Since(start)
+		permission.Notify(ctx, d)
+	}
+	return d, err
+}
+
+// Decide is a cached permission.Definitions.Decide.
+// The decision hooks are notified of every decision, cached or not
+func (c *Cache) Decide(ctx context.Context, subject string, required, scope permission.Scope) permission.Decision {
+	d, _ := c.decide(ctx, NewKey("", subject, required, scope), func(defs permission.Definitions) (permission.Decision, error) {
+		return defs.Decide(ctx, subject, required, scope), nil
+	})
+	return d
+}
+
+// Require checks wether the permissions granted to the subject, directly or through its roles,
+// match the required permission and are listed in the definitions, caching the decision.
+// Decisions made with different roles are cached separately.
+// Returns an error if the parsing or the store lookup fails
+func (c *Cache) Require(ctx context.Context, r permission.StoreReader, roles permission.Roles, subject, required string) (bool, error) {
+	req, err := permission.ParseScope(required)
+	if err != nil {
+		permission.Fail(ctx, subject, err)
+		return false, err
+	}
+
+	key := NewKey("", subject, req, nil)
+	key.Stored = true
+	key.Roles = RolesVersion(roles)
+	d, err := c.decide(ctx, key, func(defs permission.Definitions) (permission.Decision, error) {
+		s, err := permission.EffectiveScope(ctx, r, roles, subject)
+		if err != nil {
+			permission.Fail(ctx, subject, err)
+			return permission.Decision{}, err
+		}
+		return defs.Decide(ctx, subject, req, s), nil
+	})
+	return d.Allowed, err
+}
+
+// Invalidate removes the decisions of the subject.
+// The subject of a tenant is written tenant/subject, like in the keys of a store partitioned by tenant
+func (c *Cache) Invalidate(subject string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for k := range c.owners[subject] {
+		c.remove(c.entries[k])
+	}
+}
+
+// Purge removes every decision, for example after the groups changed
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purge()
+}
+
+func (c *Cache) purge() {
+	c.generation++
+	c.lru = list.New()
+	c.entries = make(map[versionedKey]*list.Element)
+	c.owners = make(map[string]map[versionedKey]struct{})
+}
+
+// Stats returns the statistics of the cache
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Size = c.lru.Len()
+	return s
+}
+
+func (c *Cache) get(k versionedKey) (permission.Decision, bool) {
+	el, ok := c.entries[k]
+	if !ok {
+		c.stats.Misses++
+		return permission.Decision{}, false
+	}
+
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !c.clock.Now().Before(e.expires) {
+		c.remove(el)
+		c.stats.Misses++
+		return permission.Decision{}, false
+	}
+
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	return e.decision, true
+}
+
+func (c *Cache) add(k versionedKey, d permission.Decision) {
+	e := entry{key: k, decision: d}
+	if c.ttl > 0 {
+		e.expires = c.clock.Now().Add(c.ttl)
+	}
+
+	if el, ok := c.entries[k]; ok {
+		el.Value = &e
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[k] = c.lru.PushFront(&e)
+	owner := k.owner()
+	if c.owners[owner] == nil {
+		c.owners[owner] = make(map[versionedKey]struct{})
+	}
+	c.owners[owner][k] = struct{}{}
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+
+	owner := e.key.owner()
+	delete(c.owners[owner], e.key)
+	if len(c.owners[owner]) == 0 {
+		delete(c.owners, owner)
+	}
+}
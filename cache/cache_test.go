@@ -0,0 +1,261 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defs = permission.Definitions{
+	{Name: "user", Subset: []string{"edit", "profile", "about"}, DefaultSubset: []string{"profile", "about"}},
+	{Name: "playlist", Subset: []string{"edit", "read"}, DefaultSubset: []string{"read"}},
+}
+
+var roles = permission.Roles{
+	{Name: "editor", Scope: permission.Scope{{Name: "playlist", Sub: "edit"}}},
+}
+
+func scope(t *testing.T, repr string) permission.Scope {
+	s, err := permission.ParseScope(repr)
+	require.NoError(t, err)
+	return s
+}
+
+// countingStore counts the lookups of the scopes
+type countingStore struct {
+	permission.StoreReader
+	mu      sync.Mutex
+	lookups int
+}
+
+func (s *countingStore) ScopeOf(ctx context.Context, subject string) (permission.Scope, error) {
+	s.mu.Lock()
+	s.lookups++
+	s.mu.Unlock()
+	return s.StoreReader.ScopeOf(ctx, subject)
+}
+
+func TestNewKey(t *testing.T) {
+	k := NewKey("acme", "alice", scope(t, "user.edit,playlist"), scope(t, "user,playlist.read,user"))
+	assert.Equal(t, Key{Tenant: "acme", Subject: "alice", Scope: "playlist.read,user", Required: "playlist,user.edit"}, k)
+	assert.Equal(t, "acme/alice", k.owner())
+	assert.Equal(t, k, NewKey("acme", "alice", scope(t, "playlist,user.edit"), scope(t, "playlist.read,user")))
+	assert.Equal(t, Key{Subject: "bob", Required: "user"}, NewKey("", "bob", scope(t, "user"), nil))
+}
+
+func TestDecide(t *testing.T) {
+	var decisions []permission.Decision
+	permission.OnDecision(permission.DecisionHookFunc(func(ctx context.Context, d permission.Decision) {
+		decisions = append(decisions, d)
+	}))
+	defer permission.OnDecision()
+
+	c := New(defs, Options{})
+	ctx := context.Background()
+
+	d := c.Decide(ctx, "alice", scope(t, "user.edit,user.profile"), scope(t, "user"))
+	assert.True(t, d.Allowed)
+	assert.Equal(t, permission.Permission{Name: "user"}, d.Match)
+
+	// cached decisions are notified too, with their own time
+	d = c.Decide(ctx, "alice", scope(t, "user.profile,user.edit"), scope(t, "user,user"))
+	assert.True(t, d.Allowed)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, d, decisions[1])
+	assert.Equal(t, decisions[0].Match, decisions[1].Match)
+	assert.False(t, decisions[1].Time.Before(decisions[0].Time))
+
+	d = c.Decide(ctx, "bob", scope(t, "user.edit"), scope(t, "user"))
+	assert.False(t, d.Allowed)
+	assert.Len(t, decisions, 3)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Size: 2}, c.Stats())
+	assert.InDelta(t, 1.0/3, c.Stats().HitRate(), 0.001)
+	assert.Zero(t, Stats{}.HitRate())
+}
+
+func TestRequire(t *testing.T) {
+	ctx := context.Background()
+	mem := permission.NewMemoryStore()
+	require.NoError(t, mem.Grant(ctx, "alice", scope(t, "user")))
+	require.NoError(t, mem.Assign(ctx, "alice", "editor"))
+	store := countingStore{StoreReader: mem}
+
+	c := New(defs, Options{})
+	for i := 0; i < 3; i++ {
+		ok, err := c.Require(ctx, &store, roles, "alice", "playlist.edit")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, store.lookups)
+
+	ok, err := c.Require(ctx, &store, roles, "alice", "user.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, store.lookups)
+
+	_, err = c.Require(ctx, &store, roles, "alice", "user.")
+	assert.Equal(t, permission.ErrBadFormat, err)
+
+	_, err = c.Require(ctx, &store, roles, "", "user")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+	_, err = c.Require(ctx, &store, roles, "", "user")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+	assert.Equal(t, 2, c.Stats().Size)
+
+	// the decisions made with a presented scope and with the store don't collide
+	assert.False(t, c.Decide(ctx, "alice", scope(t, "playlist.edit"), nil).Allowed)
+	ok, err = c.Require(ctx, &store, roles, "alice", "playlist.edit")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, c.Stats().Size)
+
+	// decisions made with other roles are not shared
+	lookups := store.lookups
+	ok, err = c.Require(ctx, &store, nil, "alice", "playlist.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, lookups+1, store.lookups)
+	assert.Equal(t, 4, c.Stats().Size)
+
+	// nor when the roles change
+	changed := permission.Roles{{Name: "editor", Scope: permission.Scope{{Name: "user", Sub: "edit"}}}}
+	ok, err = c.Require(ctx, &store, changed, "alice", "playlist.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 5, c.Stats().Size)
+}
+
+func TestDoErrors(t *testing.T) {
+	c := New(defs, Options{})
+	calls := 0
+	fn := func(permission.Definitions) (permission.Decision, error) {
+		calls++
+		return permission.Decision{}, errors.New("boom")
+	}
+
+	_, err := c.Do(Key{Subject: "alice"}, fn)
+	assert.EqualError(t, err, "boom")
+	_, err = c.Do(Key{Subject: "alice"}, fn)
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 2, calls)
+}
+
+func TestEviction(t *testing.T) {
+	c := New(defs, Options{Size: 2})
+	ctx := context.Background()
+	required := scope(t, "user")
+
+	c.Decide(ctx, "alice", required, scope(t, "user"))
+	c.Decide(ctx, "bob", required, scope(t, "user"))
+	c.Decide(ctx, "alice", required, scope(t, "user"))
+	c.Decide(ctx, "carol", required, scope(t, "user"))
+
+	// bob is the least recently used
+	c.Decide(ctx, "alice", required, scope(t, "user"))
+	c.Decide(ctx, "bob", required, scope(t, "user"))
+	assert.Equal(t, Stats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}, c.Stats())
+
+	assert.Equal(t, DefaultSize, New(defs, Options{}).size)
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := permission.ClockFunc(func() time.Time { return now })
+
+	c := New(defs, Options{TTL: time.Minute, Clock: clock})
+	ctx := context.Background()
+
+	c.Decide(ctx, "alice", scope(t, "user"), scope(t, "user"))
+	now = now.Add(59 * time.Second)
+	c.Decide(ctx, "alice", scope(t, "user"), scope(t, "user"))
+	assert.Equal(t, uint64(1), c.Stats().Hits)
+
+	now = now.Add(time.Second)
+	c.Decide(ctx, "alice", scope(t, "user"), scope(t, "user"))
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Size: 1}, c.Stats())
+}
+
+func TestSetDefinitions(t *testing.T) {
+	c := New(defs, Options{})
+	ctx := context.Background()
+	v := c.Version()
+	assert.Equal(t, Version(defs), v)
+	assert.Len(t, v, 16)
+
+	assert.True(t, c.Decide(ctx, "alice", scope(t, "user.about"), scope(t, "user")).Allowed)
+
+	c.SetDefinitions(defs)
+	assert.Equal(t, v, c.Version())
+	assert.Equal(t, 1, c.Stats().Size)
+
+	narrowed := permission.Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "about"}, DefaultSubset: []string{"profile"}},
+	}
+	c.SetDefinitions(narrowed)
+	assert.NotEqual(t, v, c.Version())
+	assert.Equal(t, narrowed, c.Definitions())
+	assert.Zero(t, c.Stats().Size)
+	assert.False(t, c.Decide(ctx, "alice", scope(t, "user.about"), scope(t, "user")).Allowed)
+
+	// a decision computed with the previous definitions is not stored
+	c.Do(Key{Subject: "bob"}, func(permission.Definitions) (permission.Decision, error) {
+		c.SetDefinitions(defs)
+		return permission.Decision{Allowed: true}, nil
+	})
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestInvalidate(t *testing.T) {
+	c := New(defs, Options{})
+	ctx := context.Background()
+
+	c.Decide(ctx, "alice", scope(t, "user"), scope(t, "user"))
+	c.Decide(ctx, "alice", scope(t, "playlist"), scope(t, "user"))
+	c.Decide(ctx, "bob", scope(t, "user"), scope(t, "user"))
+	c.Do(Key{Tenant: "acme", Subject: "alice"}, func(permission.Definitions) (permission.Decision, error) {
+		return permission.Decision{}, nil
+	})
+	assert.Equal(t, 4, c.Stats().Size)
+
+	c.Invalidate("alice")
+	assert.Equal(t, 2, c.Stats().Size)
+
+	c.Invalidate("acme/alice")
+	assert.Equal(t, 1, c.Stats().Size)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestConcurrency(t *testing.T) {
+	c := New(defs, Options{Size: 8})
+	ctx := context.Background()
+	subjects := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				subject := subjects[(i+j)%len(subjects)]
+				c.Decide(ctx, subject, permission.Scope{{Name: "user"}}, permission.Scope{{Name: "user"}})
+				if j%50 == 0 {
+					c.Invalidate(subject)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	s := c.Stats()
+	assert.Equal(t, uint64(1600), s.Hits+s.Misses)
+	assert.LessOrEqual(t, s.Size, 8)
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/asdine/permission"
+)
+
+// NewStore wraps the store to invalidate the decisions of the subjects
+// whose grants or roles change, directly or in a transaction.
+// Subjects of a partition are invalidated as tenant/subject when the partition is created
+// with permission.TenantStore on top of the returned Store
+func NewStore(s permission.Store, c *Cache) permission.Store {
+	return &store{Store: s, cache: c}
+}
+
+type store struct {
+	permission.Store
+	cache *Cache
+}
+
+func (s *store) Update(ctx context.Context, fn func(tx permission.StoreTx) error) error {
+	t := tx{subjects: make(map[string]struct{})}
+	err := s.Store.Update(ctx, func(ptx permission.StoreTx) error {
+		t.StoreTx = ptx
+		return fn(&t)
+	})
+
+	// invalidating is harmless if the transaction failed
+	for subject := range t.subjects {
+		s.cache.Invalidate(subject)
+	}
+	return err
+}
+
+func (s *store) Grant(ctx context.Context, subject string, sc permission.Scope) error {
+	defer s.cache.Invalidate(subject)
+	return s.Store.Grant(ctx, subject, sc)
+}
+
+func (s *store) Revoke(ctx context.Context, subject string, sc permission.Scope) error {
+	defer s.cache.Invalidate(subject)
+	return s.Store.Revoke(ctx, subject, sc)
+}
+
+func (s *store) Assign(ctx context.Context, subject string, roles ...string) error {
+	defer s.cache.Invalidate(subject)
+	return s.Store.Assign(ctx, subject, roles...)
+}
+
+func (s *store) Unassign(ctx context.Context, subject string, roles ...string) error {
+	defer s.cache.Invalidate(subject)
+	return s.Store.Unassign(ctx, subject, roles...)
+}
+
+// SubjectsWithPrefix implements the permission.PrefixReader interface
+func (s *store) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	return permission.SubjectsWithPrefix(ctx, s.Store, p, prefix)
+}
+
+// tx records the subjects modified during a transaction
+type tx struct {
+	permission.StoreTx
+	subjects map[string]struct{}
+}
+
+func (t *tx) Grant(ctx context.Context, subject string, s permission.Scope) error {
+	t.subjects[subject] = struct{}{}
+	return t.StoreTx.Grant(ctx, subject, s)
+}
+
+func (t *tx) Revoke(ctx context.Context, subject string, s permission.Scope) error {
+	t.subjects[subject] = struct{}{}
+	return t.StoreTx.Revoke(ctx, subject, s)
+}
+
+func (t *tx) Assign(ctx context.Context, subject string, roles ...string) error {
+	t.subjects[subject] = struct{}{}
+	return t.StoreTx.Assign(ctx, subject, roles...)
+}
+
+func (t *tx) Unassign(ctx context.Context, subject string, roles ...string) error {
+	t.subjects[subject] = struct{}{}
+	return t.StoreTx.Unassign(ctx, subject, roles...)
+}
+
+func (t *tx) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	return permission.SubjectsWithPrefix(ctx, t.StoreTx, p, prefix)
+}
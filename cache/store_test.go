@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		return NewStore(permission.NewMemoryStore(), New(defs, Options{}))
+	})
+}
+
+func TestStoreInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c := New(defs, Options{})
+	s := NewStore(permission.NewMemoryStore(), c)
+
+	require.NoError(t, s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Grant(ctx, "alice", scope(t, "user"))
+	}))
+
+	allowed := func(subject, required string) bool {
+		var ok bool
+		err := s.View(ctx, func(r permission.StoreReader) error {
+			var err error
+			ok, err = c.Require(ctx, r, roles, subject, required)
+			return err
+		})
+		assert.NoError(t, err)
+		return ok
+	}
+
+	assert.False(t, allowed("alice", "playlist.edit"))
+	assert.True(t, allowed("alice", "user.profile"))
+	assert.False(t, allowed("bob", "user"))
+	assert.Equal(t, 3, c.Stats().Size)
+
+	assert.NoError(t, s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Assign(ctx, "alice", "editor")
+	}))
+	assert.Equal(t, 1, c.Stats().Size)
+	assert.True(t, allowed("alice", "playlist.edit"))
+
+	assert.NoError(t, s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Revoke(ctx, "alice", scope(t, "user"))
+	}))
+	assert.False(t, allowed("alice", "user.profile"))
+
+	// the subjects are invalidated even if the transaction fails
+	allowed("bob", "user")
+	err := s.Update(ctx, func(tx permission.StoreTx) error {
+		tx.Unassign(ctx, "bob", "editor")
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 1, c.Stats().Size)
+	assert.Equal(t, uint64(1), c.Stats().Hits)
+}
+
+func TestStoreTenant(t *testing.T) {
+	ctx := context.Background()
+	c := New(defs, Options{})
+	s, err := permission.TenantStore(NewStore(permission.NewMemoryStore(), c), "acme")
+	require.NoError(t, err)
+
+	c.Do(Key{Tenant: "acme", Subject: "alice"}, func(permission.Definitions) (permission.Decision, error) {
+		return permission.Decision{}, nil
+	})
+	c.Do(Key{Subject: "alice"}, func(permission.Definitions) (permission.Decision, error) {
+		return permission.Decision{}, nil
+	})
+
+	require.NoError(t, s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Grant(ctx, "alice", scope(t, "user"))
+	}))
+	assert.Equal(t, 1, c.Stats().Size)
+}
+
+func TestStoreDirectInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c := New(defs, Options{})
+	mem := permission.NewMemoryStore()
+	s := NewStore(mem, c)
+
+	allowed := func(subject, required string) bool {
+		ok, err := c.Require(ctx, mem, roles, subject, required)
+		assert.NoError(t, err)
+		return ok
+	}
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user")))
+	assert.True(t, allowed("alice", "user.profile"))
+
+	require.NoError(t, s.Revoke(ctx, "alice", scope(t, "user")))
+	assert.False(t, allowed("alice", "user.profile"))
+
+	require.NoError(t, s.Assign(ctx, "alice", "editor"))
+	assert.True(t, allowed("alice", "playlist.edit"))
+
+	require.NoError(t, s.Unassign(ctx, "alice", "editor"))
+	assert.False(t, allowed("alice", "playlist.edit"))
+
+	// only the decisions of the subject are invalidated
+	assert.False(t, allowed("bob", "user"))
+	require.NoError(t, s.Grant(ctx, "bob", scope(t, "user")))
+	assert.Equal(t, 1, c.Stats().Size)
+	assert.True(t, allowed("bob", "user"))
+}
+
+func TestStoreTenantDirect(t *testing.T) {
+	ctx := context.Background()
+	c := New(defs, Options{})
+	s, err := permission.TenantStore(NewStore(permission.NewMemoryStore(), c), "acme")
+	require.NoError(t, err)
+
+	allowed := func(required string) bool {
+		d, err := c.Do(Key{Tenant: "acme", Subject: "alice", Required: required, Stored: true}, func(defs permission.Definitions) (permission.Decision, error) {
+			var d permission.Decision
+			err := s.View(ctx, func(r permission.StoreReader) error {
+				sc, err := r.ScopeOf(ctx, "alice")
+				d = defs.Decide(ctx, "alice", scope(t, required), sc)
+				return err
+			})
+			return d, err
+		})
+		assert.NoError(t, err)
+		return d.Allowed
+	}
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user")))
+	assert.True(t, allowed("user"))
+
+	require.NoError(t, s.Revoke(ctx, "alice", scope(t, "user")))
+	assert.False(t, allowed("user"))
+}
@@ -0,0 +1,376 @@
+// Package casbin converts between Casbin CSV policies and permission grants and roles.
+//
+// Only the basic RBAC model is supported:
+//
+//	p, alice, playlist, edit    alice is granted playlist.edit
+//	p, admin, user, edit        the admin role has user.edit in its scope
+//	g, bob, admin               bob is assigned the admin role
+//
+// The object is the permission name and the action its sub.
+// Lines that cannot be represented, like deny effects, domains, wildcards or role inheritance,
+// are reported as Unsupported instead of failing the whole conversion.
+package casbin
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/asdine/permission"
+)
+
+// Reasons a line is unsupported
+const (
+	ReasonUnknownType     = "unknown policy type"
+	ReasonFieldCount      = "unexpected number of fields"
+	ReasonDeny            = "deny effect"
+	ReasonWildcard        = "pattern matching"
+	ReasonBadPermission   = "object and action can't be represented as a permission"
+	ReasonRoleInheritance = "role inheritance"
+	ReasonNoSub           = "permission without sub"
+	ReasonDomain          = "domain"
+	ReasonSpaces          = "field with leading or trailing spaces"
+)
+
+// Unsupported describes a line that cannot be converted
+type Unsupported struct {
+	// Line number in the imported CSV, zero for exported lines
+	Line   int
+	Text   string
+	Reason string
+}
+
+func (u Unsupported) String() string {
+	if u.Line == 0 {
+		return fmt.Sprintf("%s: %s", u.Text, u.Reason)
+	}
+	return fmt.Sprintf("line %d: %s: %s", u.Line, u.Text, u.Reason)
+}
+
+// Policy is the representable content of a Casbin policy
+type Policy struct {
+	// Grants lists the permissions granted directly to subjects
+	Grants map[string]permission.Scope
+
+	// Roles lists the roles and their scope
+	Roles permission.Roles
+
+	// Assignments lists the roles assigned to subjects
+	Assignments map[string][]string
+}
+
+type record struct {
+	line   int
+	fields []string
+}
+
+// Parse reads a Casbin CSV policy.
+// Returns the lines that cannot be represented and an error if the CSV is malformed
+func Parse(r io.Reader) (*Policy, []Unsupported, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var records []record
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		records = append(records, record{line: line, fields: fields})
+	}
+
+	// roles are the targets of the g lines
+	roles := make(map[string]bool)
+	for _, rec := range records {
+		if rec.fields[0] == "g" && len(rec.fields) == 3 {
+			roles[rec.fields[2]] = true
+		}
+	}
+
+	p := Policy{
+		Grants:      make(map[string]permission.Scope),
+		Assignments: make(map[string][]string),
+	}
+	var unsupported []Unsupported
+
+	for _, rec := range records {
+		reason := p.add(rec.fields, roles)
+		if reason != "" {
+			unsupported = append(unsupported, Unsupported{
+				Line:   rec.line,
+				Text:   strings.Join(rec.fields, ", "),
+				Reason: reason,
+			})
+		}
+	}
+
+	for _, s := range p.Grants {
+		permission.SortScope(s)
+	}
+	for _, r := range p.Roles {
+		permission.SortScope(r.Scope)
+	}
+	sort.Slice(p.Roles, func(i, j int) bool {
+		return p.Roles[i].Name < p.Roles[j].Name
+	})
+	for _, assigned := range p.Assignments {
+		sort.Strings(assigned)
+	}
+
+	return &p, unsupported, nil
+}
+
+// add adds the line to the policy or returns why it can't
+func (p *Policy) add(fields []string, roles map[string]bool) string {
+	switch fields[0] {
+	case "p":
+		// the fifth field is either an effect or the object of a line with a domain
+		if len(fields) == 5 && fields[4] == "deny" {
+			return ReasonDeny
+		}
+		if len(fields) == 5 && fields[4] != "allow" {
+			return ReasonDomain
+		}
+		if len(fields) != 4 && len(fields) != 5 {
+			return ReasonFieldCount
+		}
+
+		sub, obj, act := fields[1], fields[2], fields[3]
+		if hasPattern(sub) || hasPattern(obj) || hasPattern(act) {
+			return ReasonWildcard
+		}
+
+		perm := permission.Permission{Name: obj, Sub: act}
+		if !representable(perm) {
+			return ReasonBadPermission
+		}
+
+		if roles[sub] {
+			role := p.Roles.Role(sub)
+			if role == nil {
+				p.Roles = append(p.Roles, permission.Role{Name: sub})
+				role = &p.Roles[len(p.Roles)-1]
+			}
+			if !role.Scope.HasPermission(perm) {
+				role.Scope = append(role.Scope, perm)
+			}
+			return ""
+		}
+
+		s := p.Grants[sub]
+		if !s.HasPermission(perm) {
+			p.Grants[sub] = append(s, perm)
+		}
+	case "g":
+		if len(fields) == 4 {
+			return ReasonDomain
+		}
+		if len(fields) != 3 {
+			return ReasonFieldCount
+		}
+
+		subject, role := fields[1], fields[2]
+		if subject == "" || role == "" {
+			return ReasonFieldCount
+		}
+		if hasPattern(subject) || hasPattern(role) {
+			return ReasonWildcard
+		}
+		if roles[subject] {
+			return ReasonRoleInheritance
+		}
+
+		if !permission.InStringSlice(p.Assignments[subject], role) {
+			p.Assignments[subject] = append(p.Assignments[subject], role)
+		}
+	default:
+		return ReasonUnknownType
+	}
+
+	return ""
+}
+
+// Import parses a Casbin CSV policy and writes its grants and role assignments
+// to the store in a single transaction.
+// Returns the roles defined by the policy and the lines that cannot be represented
+func Import(ctx context.Context, r io.Reader, store permission.Store) (permission.Roles, []Unsupported, error) {
+	p, unsupported, err := Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = store.Update(ctx, func(tx permission.StoreTx) error {
+		for subject, s := range p.Grants {
+			err := tx.Grant(ctx, subject, s)
+			if err != nil {
+				return err
+			}
+		}
+
+		for subject, roles := range p.Assignments {
+			err := tx.Assign(ctx, subject, roles...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.Roles, unsupported, nil
+}
+
+// Export writes the grants and role assignments of the store and the scopes of the roles
+// as a Casbin CSV policy, reading the store from a consistent snapshot.
+// Returns the permissions that cannot be represented
+func Export(ctx context.Context, w io.Writer, store permission.Store, roles permission.Roles) ([]Unsupported, error) {
+	p := Policy{
+		Grants:      make(map[string]permission.Scope),
+		Roles:       roles,
+		Assignments: make(map[string][]string),
+	}
+
+	err := store.View(ctx, func(r permission.StoreReader) error {
+		subjects, err := r.Subjects(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, subject := range subjects {
+			p.Grants[subject], err = r.ScopeOf(ctx, subject)
+			if err != nil {
+				return err
+			}
+
+			p.Assignments[subject], err = r.RolesOf(ctx, subject)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Write(w)
+}
+
+// Write writes the policy as Casbin CSV lines, sorted by subject.
+// Fields are quoted when needed.
+// Returns the permissions and assignments that cannot be represented
+func (p *Policy) Write(w io.Writer) ([]Unsupported, error) {
+	var unsupported []Unsupported
+	var records [][]string
+
+	add := func(fields ...string) {
+		text := strings.Join(fields, ", ")
+		for _, f := range fields {
+			// spaces around fields are trimmed by Parse
+			if f != strings.TrimSpace(f) {
+				unsupported = append(unsupported, Unsupported{Text: text, Reason: ReasonSpaces})
+				return
+			}
+		}
+		records = append(records, fields)
+	}
+
+	policy := func(subject string, s permission.Scope) {
+		for _, perm := range s {
+			if perm.Sub == "" {
+				unsupported = append(unsupported, Unsupported{
+					Text:   strings.Join([]string{"p", subject, perm.Name, perm.Sub}, ", "),
+					Reason: ReasonNoSub,
+				})
+				continue
+			}
+			add("p", subject, perm.Name, perm.Sub)
+		}
+	}
+
+	subjects := make([]string, 0, len(p.Grants))
+	for subject := range p.Grants {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		policy(subject, p.Grants[subject])
+	}
+
+	for _, r := range p.Roles {
+		policy(r.Name, r.Scope)
+	}
+
+	subjects = subjects[:0]
+	for subject := range p.Assignments {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		for _, role := range p.Assignments[subject] {
+			add("g", subject, role)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	err := cw.WriteAll(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return unsupported, nil
+}
+
+// Enforce reports whether the subject, directly or through its roles, can perform
+// the action on the object, like the Casbin basic RBAC model would.
+// It allows cross checking decisions between the two systems
+func (p *Policy) Enforce(subject, obj, act string) bool {
+	perm := permission.Permission{Name: obj, Sub: act}
+	s := p.Grants[subject]
+	if s.HasPermission(perm) {
+		return true
+	}
+
+	for _, name := range p.Assignments[subject] {
+		role := p.Roles.Role(name)
+		if role != nil && role.Scope.HasPermission(perm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPattern reports whether the field uses Casbin matching functions or wildcards
+func hasPattern(field string) bool {
+	return strings.ContainsAny(field, "*()")
+}
+
+// representable reports whether the permission survives a round trip through its text form
+func representable(p permission.Permission) bool {
+	if p.Name == "" || p.Sub == "" {
+		return false
+	}
+
+	q, err := permission.Parse(p.String())
+	return err == nil && q.Equal(p)
+}
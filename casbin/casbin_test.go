@@ -0,0 +1,151 @@
+package casbin
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvPolicy = `# basic rbac
+p, alice, playlist, edit
+p, alice, playlist, edit
+p, admin, user, edit
+p, admin, user, delete
+g, bob, admin
+
+p, carol, playlist, read, deny
+p, carol, /data/*, read
+p, carol, playlist
+p, carol, user.x, read
+g, admin, superadmin
+g, dave, admin, domain1
+g2, carol, admin
+p, carol, domain1, playlist, read
+`
+
+func TestParse(t *testing.T) {
+	p, unsupported, err := Parse(strings.NewReader(csvPolicy))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]permission.Scope{
+		"alice": {{Name: "playlist", Sub: "edit"}},
+	}, p.Grants)
+	assert.Equal(t, permission.Roles{
+		{Name: "admin", Scope: permission.Scope{{Name: "user", Sub: "delete"}, {Name: "user", Sub: "edit"}}},
+	}, p.Roles)
+	assert.Equal(t, map[string][]string{"bob": {"admin"}}, p.Assignments)
+
+	assert.Equal(t, []Unsupported{
+		{Line: 8, Text: "p, carol, playlist, read, deny", Reason: ReasonDeny},
+		{Line: 9, Text: "p, carol, /data/*, read", Reason: ReasonWildcard},
+		{Line: 10, Text: "p, carol, playlist", Reason: ReasonFieldCount},
+		{Line: 11, Text: "p, carol, user.x, read", Reason: ReasonBadPermission},
+		{Line: 12, Text: "g, admin, superadmin", Reason: ReasonRoleInheritance},
+		{Line: 13, Text: "g, dave, admin, domain1", Reason: ReasonDomain},
+		{Line: 14, Text: "g2, carol, admin", Reason: ReasonUnknownType},
+		{Line: 15, Text: "p, carol, domain1, playlist, read", Reason: ReasonDomain},
+	}, unsupported)
+	assert.Equal(t, "line 8: p, carol, playlist, read, deny: deny effect", unsupported[0].String())
+
+	_, _, err = Parse(strings.NewReader(`p, "alice, playlist, edit`))
+	assert.Error(t, err)
+}
+
+func TestEnforce(t *testing.T) {
+	p, _, err := Parse(strings.NewReader(csvPolicy))
+	require.NoError(t, err)
+
+	assert.True(t, p.Enforce("alice", "playlist", "edit"))
+	assert.False(t, p.Enforce("alice", "user", "edit"))
+	assert.True(t, p.Enforce("bob", "user", "edit"))
+	assert.False(t, p.Enforce("bob", "playlist", "edit"))
+	assert.False(t, p.Enforce("carol", "playlist", "read"))
+}
+
+func TestImportExport(t *testing.T) {
+	ctx := context.Background()
+	store := permission.NewMemoryStore()
+
+	roles, unsupported, err := Import(ctx, strings.NewReader(csvPolicy), store)
+	require.NoError(t, err)
+	assert.Len(t, unsupported, 8)
+	assert.Equal(t, "admin", roles[0].Name)
+
+	s, err := store.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, permission.Scope{{Name: "playlist", Sub: "edit"}}, s)
+
+	assigned, err := store.RolesOf(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, assigned)
+
+	// decisions match on both sides
+	defs := permission.Definitions{
+		{Name: "playlist", Subset: []string{"edit", "read"}},
+		{Name: "user", Subset: []string{"edit", "delete"}},
+	}
+	p, _, err := Parse(strings.NewReader(csvPolicy))
+	require.NoError(t, err)
+	for _, subject := range []string{"alice", "bob", "carol"} {
+		effective, err := permission.EffectiveScope(ctx, store, roles, subject)
+		require.NoError(t, err)
+
+		for _, perm := range []permission.Permission{{Name: "playlist", Sub: "edit"}, {Name: "user", Sub: "edit"}, {Name: "user", Sub: "delete"}} {
+			assert.Equal(t, p.Enforce(subject, perm.Name, perm.Sub), defs.RequireScope(permission.Scope{perm}, effective), subject+" "+perm.String())
+		}
+	}
+
+	require.NoError(t, store.Grant(ctx, "dave", permission.Scope{{Name: "user"}, {Name: "user", Sub: "edit"}}))
+
+	var buf bytes.Buffer
+	unsupported, err = Export(ctx, &buf, store, roles)
+	require.NoError(t, err)
+	assert.Equal(t, []Unsupported{{Text: "p, dave, user, ", Reason: ReasonNoSub}}, unsupported)
+	assert.Equal(t, `p,alice,playlist,edit
+p,dave,user,edit
+p,admin,user,delete
+p,admin,user,edit
+g,bob,admin
+`, buf.String())
+
+	// the export can be imported back
+	q, unsupported, err := Parse(&buf)
+	require.NoError(t, err)
+	assert.Len(t, unsupported, 0)
+	assert.Equal(t, roles, q.Roles)
+}
+
+func TestWriteEscaping(t *testing.T) {
+	p := Policy{
+		Grants: map[string]permission.Scope{
+			"doe, john":   {{Name: "user", Sub: "edit"}},
+			`say "hi"`:    {{Name: "user", Sub: "edit"}},
+			" padded":     {{Name: "user", Sub: "edit"}},
+			"line\nbreak": {{Name: "user", Sub: "edit"}},
+		},
+		Assignments: map[string][]string{"doe, john": {"admin, ops"}},
+	}
+
+	var buf bytes.Buffer
+	unsupported, err := p.Write(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []Unsupported{{Text: "p,  padded, user, edit", Reason: ReasonSpaces}}, unsupported)
+	assert.Equal(t, `p,"doe, john",user,edit
+p,"line
+break",user,edit
+p,"say ""hi""",user,edit
+g,"doe, john","admin, ops"
+`, buf.String())
+
+	q, unsupported, err := Parse(&buf)
+	require.NoError(t, err)
+	assert.Empty(t, unsupported)
+	delete(p.Grants, " padded")
+	assert.Equal(t, p.Grants, q.Grants)
+	assert.Equal(t, p.Assignments, q.Assignments)
+}
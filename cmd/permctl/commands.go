@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asdine/permission"
+)
+
+func init() {
+	register("validate", command{
+		usage: "-defs FILE",
+		help:  "Validate a JSON definitions file.",
+		run:   runValidate,
+	})
+	register("parse", command{
+		usage: "SCOPE",
+		help:  "Parse a scope and print it sorted and without duplicates.",
+		run:   runParse,
+	})
+	register("require", command{
+		usage: "-defs FILE REQUIRED SCOPE",
+		help:  "Check wether the scope satisfies one of the required permissions and explain why.",
+		run:   runRequire,
+	})
+	register("expand", command{
+		usage: "-defs FILE SCOPE",
+		help:  "Replace the permissions only specified by their name by their default subset.",
+		run:   runExpand,
+	})
+}
+
+// text returns the text representation of the scope
+func text(s permission.Scope) string {
+	raw, _ := s.MarshalText()
+	return string(raw)
+}
+
+// list returns the text representation of every permission of the scope
+func list(s permission.Scope) []string {
+	l := make([]string, len(s))
+	for i, p := range s {
+		l[i] = p.String()
+	}
+	return l
+}
+
+func runValidate(e *env, args []string) error {
+	_, err := e.parse(e.flags("validate"), args, 0)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	var defErr *permission.DefinitionError
+	if errors.As(err, &defErr) {
+		err = e.print(map[string]interface{}{
+			"valid": false,
+			"index": defErr.Index,
+			"name":  defErr.Name,
+			"error": defErr.Err.Error(),
+		}, fmt.Sprintf("invalid: %s", defErr))
+		if err != nil {
+			return err
+		}
+		return errFailure
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.print(map[string]interface{}{
+		"valid":       true,
+		"definitions": len(defs),
+	}, fmt.Sprintf("ok: %d definitions", len(defs)))
+}
+
+func runParse(e *env, args []string) error {
+	args, err := e.parse(e.flags("parse"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	s, err := permission.ParseScope(args[0])
+	if err != nil {
+		return err
+	}
+
+	return e.print(parsed(s))
+}
+
+// parsed returns the JSON and text results of the parse command
+func parsed(s permission.Scope) (map[string]interface{}, string) {
+	s = s.Normalize()
+	return map[string]interface{}{
+		"scope":      list(s),
+		"normalized": text(s),
+	}, text(s)
+}
+
+// explanation of the decision for a single required permission
+type explanation struct {
+	Permission string            `json:"permission"`
+	Allowed    bool              `json:"allowed"`
+	Reason     permission.Reason `json:"reason"`
+	Match      string            `json:"match,omitempty"`
+}
+
+func explain(d permission.Decision) explanation {
+	x := explanation{Allowed: d.Allowed, Reason: d.Reason}
+	if len(d.Required) == 1 {
+		x.Permission = d.Required[0].String()
+	}
+	if d.Allowed {
+		x.Match = d.Match.String()
+	}
+	return x
+}
+
+func (x explanation) String() string {
+	switch x.Reason {
+	case permission.ReasonGranted:
+		return fmt.Sprintf("%s: granted by %s", x.Permission, x.Match)
+	case permission.ReasonUndefined:
+		return fmt.Sprintf("%s: not defined", x.Permission)
+	}
+	return fmt.Sprintf("%s: not granted", x.Permission)
+}
+
+func runRequire(e *env, args []string) error {
+	args, err := e.parse(e.flags("require"), args, 2)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	required, err := permission.ParseScope(args[0])
+	if err != nil {
+		return fmt.Errorf("required: %w", err)
+	}
+
+	scope, err := permission.ParseScope(args[1])
+	if err != nil {
+		return fmt.Errorf("scope: %w", err)
+	}
+
+	v, txt, allowed := decided(defs, required, scope)
+	err = e.print(v, txt)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return errFailure
+	}
+	return nil
+}
+
+// decided returns the JSON and text results of the require command and wether the scope is allowed
+func decided(defs permission.Definitions, required, scope permission.Scope) (map[string]interface{}, string, bool) {
+	ctx := context.Background()
+	d := defs.Decide(ctx, "", required, scope)
+
+	// explain every required permission, the requirement is satisfied by any of them
+	var explanations []explanation
+	lines := []string{"denied"}
+	if d.Allowed {
+		lines[0] = "allowed"
+	}
+	for _, p := range required {
+		x := explain(defs.Decide(ctx, "", permission.Scope{p}, scope))
+		explanations = append(explanations, x)
+		lines = append(lines, "  "+x.String())
+	}
+
+	return map[string]interface{}{
+		"allowed":     d.Allowed,
+		"reason":      d.Reason,
+		"match":       explain(d).Match,
+		"required":    list(required),
+		"scope":       list(scope),
+		"explanation": explanations,
+	}, strings.Join(lines, "\n"), d.Allowed
+}
+
+func runExpand(e *env, args []string) error {
+	args, err := e.parse(e.flags("expand"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	s, err := permission.ParseScope(args[0])
+	if err != nil {
+		return err
+	}
+
+	return e.print(expanded(defs, s))
+}
+
+// expanded returns the JSON and text results of the expand command
+func expanded(defs permission.Definitions, s permission.Scope) (map[string]interface{}, string) {
+	s = defs.Expand(s)
+	return map[string]interface{}{
+		"scope":    list(s),
+		"expanded": text(s),
+	}, text(s)
+}
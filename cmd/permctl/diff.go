@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/asdine/permission"
+)
+
+func init() {
+	register("diff", command{
+		usage: "[-corpus FILE] [-fail-on KIND] OLD NEW",
+		help: "Classify the changes between two definitions files as additive, narrowing or breaking.\n" +
+			"The corpus is a JSON object mapping identifiers to scopes, like stored tokens,\n" +
+			"the scopes that would lose permissions are listed.",
+		run: runDiff,
+	})
+}
+
+// failOn is the -fail-on flag
+type failOn struct {
+	kind  permission.ChangeKind
+	never bool
+}
+
+func (f *failOn) String() string {
+	if f.never {
+		return "never"
+	}
+	return f.kind.String()
+}
+
+func (f *failOn) Set(value string) error {
+	f.never = value == "never"
+	if f.never {
+		return nil
+	}
+
+	err := f.kind.UnmarshalText([]byte(value))
+	if err != nil {
+		return fmt.Errorf("unknown kind %q", value)
+	}
+	return nil
+}
+
+func (f *failOn) fails(changes permission.Changes) bool {
+	return !f.never && len(changes) > 0 && changes.Max() >= f.kind
+}
+
+// loadCorpus reads a JSON object mapping identifiers to scopes
+func loadCorpus(path string) (map[string]permission.Scope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var corpus map[string]permission.Scope
+	err = json.Unmarshal(data, &corpus)
+	if err != nil {
+		return nil, fmt.Errorf("corpus: %w", err)
+	}
+	return corpus, nil
+}
+
+type jsonChange struct {
+	Kind        permission.ChangeKind `json:"kind"`
+	Name        string                `json:"name"`
+	Sub         string                `json:"sub,omitempty"`
+	Description string                `json:"description"`
+}
+
+func runDiff(e *env, args []string) error {
+	fs := e.flags("diff")
+	corpusPath := fs.String("corpus", "", "path of a JSON object mapping identifiers to scopes")
+	fail := failOn{kind: permission.Breaking}
+	fs.Var(&fail, "fail-on", "exit with 1 if a change is at least additive, narrowing, breaking or never")
+
+	args, err := e.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	old, err := loadDefinitions(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	next, err := loadDefinitions(args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+
+	changes := permission.DiffDefinitions(old, next)
+
+	var lines []string
+	jsonChanges := []jsonChange{}
+	for _, c := range changes {
+		lines = append(lines, c.String())
+		jsonChanges = append(jsonChanges, jsonChange{Kind: c.Kind, Name: c.Name, Sub: c.Sub, Description: c.Description})
+	}
+	if len(changes) == 0 {
+		lines = append(lines, "no changes")
+	}
+
+	affected := map[string][]string{}
+	if *corpusPath != "" {
+		corpus, err := loadCorpus(*corpusPath)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(corpus))
+		for id := range corpus {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			lost := permission.Lost(old, next, corpus[id])
+			if len(lost) > 0 {
+				affected[id] = list(lost)
+				lines = append(lines, fmt.Sprintf("%s loses %s", id, text(lost)))
+			}
+		}
+	}
+
+	err = e.print(map[string]interface{}{
+		"changes":  jsonChanges,
+		"max":      changes.Max(),
+		"affected": affected,
+	}, strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+
+	if fail.fails(changes) {
+		return errFailure
+	}
+	return nil
+}
+
+var _ flag.Value = (*failOn)(nil)
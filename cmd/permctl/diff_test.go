@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defsV2 = "testdata/definitions-v2.json"
+
+func TestDiff(t *testing.T) {
+	code, stdout, _ := permctl("diff", "-corpus", "testdata/corpus.json", defs, defsV2)
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, `additive: playlist.delete: added to the subset
+narrowing: user.about: removed from the default subset
+breaking: user.email: removed from the subset
+token-1 loses user.about
+token-2 loses user.email
+`, stdout)
+
+	code, _, _ = permctl("diff", "-fail-on", "never", defs, defsV2)
+	assert.Equal(t, exitOK, code)
+
+	code, stdout, _ = permctl("diff", defs, defs)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "no changes\n", stdout)
+
+	code, _, _ = permctl("diff", "-fail-on", "additive", defs, defs)
+	assert.Equal(t, exitOK, code)
+
+	code, _, stderr := permctl("diff", "-fail-on", "nope", defs, defs)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, `unknown kind "nope"`)
+
+	code, _, stderr = permctl("diff", defs, "testdata/invalid.json")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "testdata/invalid.json: definition 1")
+}
+
+func TestDiffJSON(t *testing.T) {
+	code, stdout, _ := permctl("diff", "-json", "-fail-on", "narrowing", "-corpus", "testdata/corpus.json", defsV2, defs)
+	assert.Equal(t, exitFailure, code)
+
+	var result struct {
+		Changes  []jsonChange
+		Max      string
+		Affected map[string][]string
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "breaking", result.Max)
+	require.Len(t, result.Changes, 3)
+	assert.Equal(t, jsonChange{Kind: permission.Breaking, Name: "playlist", Sub: "delete", Description: "removed from the subset"}, result.Changes[0])
+	assert.Equal(t, map[string][]string{}, result.Affected)
+}
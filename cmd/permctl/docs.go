@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/asdine/permission/docs"
+)
+
+func init() {
+	register("docs", command{
+		usage: "-defs FILE [-format markdown|html] [-title TITLE]",
+		help:  "Render the documentation of the definitions as Markdown or HTML.",
+		run:   runDocs,
+	})
+}
+
+func runDocs(e *env, args []string) error {
+	fs := e.flags("docs")
+	format := fs.String("format", "markdown", "output format, markdown or html")
+	title := fs.String("title", docs.DefaultTitle, "title of the document")
+
+	_, err := e.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	opts := docs.Options{Title: *title}
+	if e.json {
+		return e.print(docs.NewCatalogue(defs, opts), "")
+	}
+
+	switch *format {
+	case "markdown", "md":
+		return docs.Markdown(e.stdout, defs, opts)
+	case "html":
+		return docs.HTML(e.stdout, defs, opts)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocs(t *testing.T) {
+	code, stdout, _ := permctl("docs", "-defs", defs, "-title", "Scopes")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "# Scopes\n")
+	assert.Contains(t, stdout, "| `user.profile` | yes |  |\n")
+	assert.Contains(t, stdout, "| `playlist` | `playlist.read`, `playlist.share` |\n")
+
+	code, stdout, _ = permctl("docs", "-defs", defs, "-format", "html")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "<h1>Permissions</h1>")
+	assert.Contains(t, stdout, "<tr><td><code>user.about</code></td><td>yes</td><td></td></tr>")
+
+	code, stdout, _ = permctl("docs", "-defs", defs, "-json")
+	assert.Equal(t, exitOK, code)
+	var catalogue struct {
+		Title    string
+		Sections []json.RawMessage
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &catalogue))
+	assert.Equal(t, "Permissions", catalogue.Title)
+	assert.Len(t, catalogue.Sections, 2)
+
+	code, _, stderr := permctl("docs", "-defs", defs, "-format", "pdf")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, `unknown format "pdf"`)
+
+	code, _, stderr = permctl("docs")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "the -defs flag is required")
+}
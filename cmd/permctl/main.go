@@ -0,0 +1,181 @@
+// Command permctl validates definitions and evaluates scopes from the command line.
+//
+// Usage:
+//
+//	permctl validate -defs definitions.json
+//	permctl parse SCOPE
+//	permctl require -defs definitions.json REQUIRED SCOPE
+//	permctl expand -defs definitions.json SCOPE
+//	permctl diff [-corpus scopes.json] [-fail-on breaking] OLD NEW
+//	permctl docs -defs definitions.json [-format markdown|html]
+//	permctl scopes -defs definitions.json
+//	permctl openapi -defs definitions.json openapi.yaml
+//	permctl repl -defs definitions.json
+//
+// Every command accepts -json to print its result as JSON,
+// and -delimiter and -separator to change the permission syntax.
+// The exit code is 0 on success, 1 if the definitions are invalid, the requirement is not satisfied
+// or the diff contains changes of the -fail-on kind, and 2 on usage or input errors.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/asdine/permission"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	exitFailure
+	exitError
+)
+
+// errFailure is returned by commands that ran but whose result is negative
+var errFailure = errors.New("failure")
+
+type command struct {
+	usage string
+	help  string
+	run   func(e *env, args []string) error
+}
+
+var commands = map[string]command{}
+
+func register(name string, c command) {
+	commands[name] = c
+}
+
+// env holds the flags shared by every command and the outputs
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	json      bool
+	defsPath  string
+	delimiter string
+	separator string
+}
+
+// flags returns the flag set of the command with the shared flags
+func (e *env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.BoolVar(&e.json, "json", false, "print the result as JSON")
+	fs.StringVar(&e.defsPath, "defs", "", "path of the JSON definitions file")
+	fs.StringVar(&e.delimiter, "delimiter", ".", "delimiter between the name and the sub of a permission")
+	fs.StringVar(&e.separator, "separator", ",", "separator between the permissions of a scope")
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: permctl %s %s\n\n%s\n\n", name, commands[name].usage, commands[name].help)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags and configures the permission syntax.
+// Returns the positional arguments, which must be exactly n
+func (e *env) parse(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != n {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+
+	permission.Delimiter(e.delimiter)
+	permission.Separator(e.separator)
+	return fs.Args(), nil
+}
+
+// definitions loads the definitions file given by -defs
+func (e *env) definitions() (permission.Definitions, error) {
+	if e.defsPath == "" {
+		return nil, errors.New("the -defs flag is required")
+	}
+
+	return loadDefinitions(e.defsPath)
+}
+
+func loadDefinitions(path string) (permission.Definitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return permission.LoadDefinitions(data)
+}
+
+// print writes v as indented JSON if -json is set, text otherwise
+func (e *env) print(v interface{}, text string) error {
+	if !e.json {
+		_, err := fmt.Fprintln(e.stdout, text)
+		return err
+	}
+
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: permctl <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitError
+	}
+
+	c, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "permctl: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitError
+	}
+
+	e := env{stdin: stdin, stdout: stdout, stderr: stderr}
+	err := c.run(&e, args[1:])
+
+	// restore the default syntax, run can be called several times in tests
+	permission.Delimiter(".")
+	permission.Separator(",")
+
+	switch {
+	case err == nil:
+		return exitOK
+	case err == errFailure:
+		return exitFailure
+	case err == flag.ErrHelp:
+		return exitError
+	}
+
+	fmt.Fprintf(stderr, "permctl: %s\n", err)
+	return exitError
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
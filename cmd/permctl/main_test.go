@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defs = "testdata/definitions.json"
+
+func permctl(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(""), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := permctl()
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "usage: permctl <command>")
+	assert.Contains(t, stderr, "require")
+
+	code, _, stderr = permctl("nope")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+
+	code, _, stderr = permctl("parse")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "usage: permctl parse SCOPE")
+}
+
+func TestValidate(t *testing.T) {
+	code, stdout, _ := permctl("validate", "-defs", defs)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "ok: 2 definitions\n", stdout)
+
+	code, stdout, _ = permctl("validate", "-defs", "testdata/invalid.json")
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "invalid: definition 1 (playlist): The default subset is not included in the subset\n", stdout)
+
+	code, stdout, _ = permctl("validate", "-json", "-defs", "testdata/invalid.json")
+	assert.Equal(t, exitFailure, code)
+	assert.JSONEq(t, `{"valid": false, "index": 1, "name": "playlist", "error": "The default subset is not included in the subset"}`, stdout)
+
+	code, _, stderr := permctl("validate", "-defs", "testdata/missing.json")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "no such file")
+
+	code, _, stderr = permctl("validate")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "the -defs flag is required")
+}
+
+func TestParse(t *testing.T) {
+	code, stdout, _ := permctl("parse", "user.edit,playlist,user.edit")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "playlist,user.edit\n", stdout)
+
+	code, stdout, _ = permctl("parse", "-json", "-delimiter", ":", "-separator", " ", "user:edit playlist")
+	assert.Equal(t, exitOK, code)
+	assert.JSONEq(t, `{"scope": ["playlist", "user:edit"], "normalized": "playlist user:edit"}`, stdout)
+
+	code, _, stderr := permctl("parse", "user.")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "not in the correct format")
+}
+
+func TestRequire(t *testing.T) {
+	code, stdout, _ := permctl("require", "-defs", defs, "user.edit,user.profile", "user")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `allowed
+  user.edit: not granted
+  user.profile: granted by user
+`, stdout)
+
+	code, stdout, _ = permctl("require", "-defs", defs, "user.edit,repo", "user.profile")
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, `denied
+  user.edit: not granted
+  repo: not defined
+`, stdout)
+
+	code, stdout, _ = permctl("require", "-json", "-defs", defs, "playlist", "playlist.read")
+	assert.Equal(t, exitOK, code)
+
+	var result struct {
+		Allowed     bool
+		Reason      string
+		Match       string
+		Required    []string
+		Scope       []string
+		Explanation []explanation
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, "granted", result.Reason)
+	assert.Equal(t, "playlist.read", result.Match)
+	assert.Equal(t, []string{"playlist"}, result.Required)
+	assert.Equal(t, []string{"playlist.read"}, result.Scope)
+	assert.Equal(t, []explanation{{Permission: "playlist", Allowed: true, Reason: "granted", Match: "playlist.read"}}, result.Explanation)
+
+	code, _, stderr := permctl("require", "-defs", defs, "user.", "user")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "required: ")
+
+	code, _, stderr = permctl("require", "-defs", defs, "user", "")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "scope: ")
+}
+
+func TestExpand(t *testing.T) {
+	code, stdout, _ := permctl("expand", "-defs", defs, "user,playlist.edit,repo")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "playlist.edit,repo,user.about,user.profile\n", stdout)
+
+	code, stdout, _ = permctl("expand", "-json", "-defs", defs, "playlist")
+	assert.Equal(t, exitOK, code)
+	assert.JSONEq(t, `{"scope": ["playlist.read", "playlist.share"], "expanded": "playlist.read,playlist.share"}`, stdout)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/asdine/permission/openapi"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register("scopes", command{
+		usage: "-defs FILE",
+		help:  "Print the scopes map of an OpenAPI OAuth2 security scheme generated from the definitions.",
+		run:   runScopes,
+	})
+	register("openapi", command{
+		usage: "-defs FILE SPEC",
+		help: "Check that the scopes required by the operations of an OpenAPI 3 document\n" +
+			"can be parsed, are defined and are declared by their security scheme.",
+		run: runOpenAPI,
+	})
+}
+
+func runScopes(e *env, args []string) error {
+	_, err := e.parse(e.flags("scopes"), args, 0)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	scopes := openapi.Scopes(defs)
+	raw, err := yaml.Marshal(map[string]interface{}{"scopes": scopes})
+	if err != nil {
+		return err
+	}
+
+	return e.print(scopes, strings.TrimSuffix(string(raw), "\n"))
+}
+
+type jsonProblem struct {
+	Path        string `json:"path,omitempty"`
+	Method      string `json:"method,omitempty"`
+	OperationID string `json:"operationId,omitempty"`
+	Scheme      string `json:"scheme"`
+	Scope       string `json:"scope"`
+	Error       string `json:"error"`
+}
+
+func runOpenAPI(e *env, args []string) error {
+	args, err := e.parse(e.flags("openapi"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	problems := openapi.Check(doc, defs)
+
+	lines := []string{"ok"}
+	jsonProblems := []jsonProblem{}
+	if len(problems) > 0 {
+		lines = lines[:0]
+	}
+	for i := range problems {
+		p := &problems[i]
+		lines = append(lines, p.Error())
+		jsonProblems = append(jsonProblems, jsonProblem{
+			Path:        p.Path,
+			Method:      p.Method,
+			OperationID: p.OperationID,
+			Scheme:      p.Scheme,
+			Scope:       p.Scope,
+			Error:       p.Err.Error(),
+		})
+	}
+
+	err = e.print(map[string]interface{}{
+		"valid":    len(problems) == 0,
+		"problems": jsonProblems,
+	}, strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return errFailure
+	}
+	return nil
+}
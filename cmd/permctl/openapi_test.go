@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopes(t *testing.T) {
+	code, stdout, _ := permctl("scopes", "-defs", defs)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "scopes:\n")
+	assert.Contains(t, stdout, "    playlist: Implies playlist.read, playlist.share\n")
+	assert.Contains(t, stdout, `    user.edit: ""`)
+
+	code, stdout, _ = permctl("scopes", "-defs", defs, "-json")
+	assert.Equal(t, exitOK, code)
+	var scopes map[string]string
+	require.NoError(t, json.Unmarshal([]byte(stdout), &scopes))
+	assert.Len(t, scopes, 10)
+}
+
+func TestOpenAPI(t *testing.T) {
+	code, stdout, _ := permctl("openapi", "-defs", defs, "testdata/openapi.yaml")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stdout, "GET /me/email (getEmail): oauth: user.email: The scope is not declared by the security scheme\n")
+	assert.Contains(t, stdout, "GET /playlists: oauth: playlist.list: The permission is not defined\n")
+	assert.Contains(t, stdout, "POST /playlists (createPlaylist): openid: The security scheme is not declared by the document\n")
+
+	code, stdout, _ = permctl("openapi", "-defs", defs, "-json", "testdata/openapi.yaml")
+	assert.Equal(t, exitFailure, code)
+	var result struct {
+		Valid    bool
+		Problems []jsonProblem
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.False(t, result.Valid)
+	assert.Equal(t, jsonProblem{Path: "/playlists", Method: "GET", Scheme: "oauth", Scope: "playlist.list", Error: "The permission is not defined"}, result.Problems[1])
+
+	code, _, stderr := permctl("openapi", "-defs", defs, "testdata/nope.yaml")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "nope.yaml")
+}
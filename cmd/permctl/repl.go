@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/asdine/permission"
+	"github.com/peterh/liner"
+)
+
+func init() {
+	register("repl", command{
+		usage: "-defs FILE [-history FILE]",
+		help: "Start an interactive session to parse, expand and compare scopes and evaluate requirements.\n" +
+			"Type help in the session to list the commands.",
+		run: runRepl,
+	})
+}
+
+// errQuit ends the session
+var errQuit = errors.New("quit")
+
+// prompter reads the lines of a session
+type prompter interface {
+	Prompt(prompt string) (string, error)
+}
+
+// scanner reads the lines of a session that is not attached to a terminal
+type scanner struct {
+	*bufio.Scanner
+}
+
+func (l scanner) Prompt(string) (string, error) {
+	if !l.Scan() {
+		if l.Err() != nil {
+			return "", l.Err()
+		}
+		return "", io.EOF
+	}
+	return l.Text(), nil
+}
+
+type replCommand struct {
+	usage string
+	help  string
+	nargs int
+	run   func(r *repl, scopes []permission.Scope) error
+}
+
+var replCommands = map[string]replCommand{
+	"parse": {
+		usage: "SCOPE",
+		help:  "print the scope sorted and without duplicates, the default when no command is given",
+		nargs: 1,
+		run: func(r *repl, s []permission.Scope) error {
+			return r.e.print(parsed(s[0]))
+		},
+	},
+	"expand": {
+		usage: "SCOPE",
+		help:  "replace the permissions only specified by their name by their default subset",
+		nargs: 1,
+		run: func(r *repl, s []permission.Scope) error {
+			return r.e.print(expanded(r.defs, s[0]))
+		},
+	},
+	"require": {
+		usage: "REQUIRED SCOPE",
+		help:  "check wether the scope satisfies one of the required permissions and explain why",
+		nargs: 2,
+		run: func(r *repl, s []permission.Scope) error {
+			v, txt, _ := decided(r.defs, s[0], s[1])
+			return r.e.print(v, txt)
+		},
+	},
+	"union": {
+		usage: "A B",
+		help:  "print the permissions of A or B",
+		nargs: 2,
+		run: func(r *repl, s []permission.Scope) error {
+			return r.e.print(set(s[0].Union(s[1])))
+		},
+	},
+	"intersect": {
+		usage: "A B",
+		help:  "print the permissions of A that are also in B",
+		nargs: 2,
+		run: func(r *repl, s []permission.Scope) error {
+			return r.e.print(set(s[0].Intersect(s[1])))
+		},
+	},
+	"difference": {
+		usage: "A B",
+		help:  "print the permissions of A that are not in B",
+		nargs: 2,
+		run: func(r *repl, s []permission.Scope) error {
+			return r.e.print(set(s[0].Difference(s[1])))
+		},
+	},
+}
+
+// set returns the JSON and text results of a set operation
+func set(s permission.Scope) (map[string]interface{}, string) {
+	if len(s) == 0 {
+		return map[string]interface{}{"scope": []string{}}, "(empty)"
+	}
+	return map[string]interface{}{"scope": list(s)}, text(s)
+}
+
+type repl struct {
+	e    *env
+	defs permission.Definitions
+
+	// names are the defined permissions, used for completion
+	names []string
+}
+
+func newRepl(e *env, defs permission.Definitions) *repl {
+	r := repl{e: e, defs: defs}
+	for _, def := range defs {
+		r.names = append(r.names, def.Name)
+		for _, sub := range def.Subset {
+			r.names = append(r.names, permission.Permission{Name: def.Name, Sub: sub}.String())
+		}
+	}
+	sort.Strings(r.names)
+	return &r
+}
+
+// eval runs a line of the session
+func (r *repl) eval(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch fields[0] {
+	case "exit", "quit":
+		return errQuit
+	case "help":
+		r.help()
+		return nil
+	case "defs":
+		r.list()
+		return nil
+	}
+
+	name, args := fields[0], fields[1:]
+	c, ok := replCommands[name]
+	if !ok {
+		name, args = "parse", fields
+		c = replCommands[name]
+	}
+
+	if len(args) != c.nargs {
+		return fmt.Errorf("usage: %s %s", name, c.usage)
+	}
+
+	scopes := make([]permission.Scope, len(args))
+	for i, arg := range args {
+		s, err := permission.ParseScope(arg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		scopes[i] = s
+	}
+
+	return c.run(r, scopes)
+}
+
+func (r *repl) help() {
+	names := make([]string, 0, len(replCommands))
+	for name := range replCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := replCommands[name]
+		fmt.Fprintf(r.e.stdout, "  %-26s %s\n", name+" "+c.usage, c.help)
+	}
+	fmt.Fprintf(r.e.stdout, "  %-26s %s\n", "defs", "list the definitions")
+	fmt.Fprintf(r.e.stdout, "  %-26s %s\n", "exit", "end the session")
+}
+
+func (r *repl) list() {
+	for _, def := range r.defs {
+		fmt.Fprintf(r.e.stdout, "%s: subset %s, default %s\n", def.Name,
+			strings.Join(def.Subset, " "), strings.Join(def.DefaultSubset, " "))
+	}
+}
+
+// complete returns the lines completing the last word of the line,
+// with a command name for the first word and a defined permission otherwise
+func (r *repl) complete(line string) []string {
+	i := strings.LastIndex(line, " ")
+	head, word := line[:i+1], line[i+1:]
+
+	var candidates []string
+	if strings.TrimSpace(head) == "" {
+		candidates = append(candidates, "defs", "exit", "help")
+		for name := range replCommands {
+			candidates = append(candidates, name)
+		}
+		candidates = append(candidates, r.names...)
+		sort.Strings(candidates)
+	} else {
+		candidates = r.names
+	}
+
+	// only complete the last permission of a scope
+	if j := strings.LastIndex(word, r.e.separator); j >= 0 {
+		head, word = head+word[:j+len(r.e.separator)], word[j+len(r.e.separator):]
+	}
+
+	var completions []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			completions = append(completions, head+c)
+		}
+	}
+	return completions
+}
+
+// historyPath returns the default path of the history file
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".permctl_history")
+}
+
+func runRepl(e *env, args []string) error {
+	fs := e.flags("repl")
+	history := fs.String("history", historyPath(), "path of the history file, empty to disable it")
+
+	_, err := e.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	defs, err := e.definitions()
+	if err != nil {
+		return err
+	}
+
+	r := newRepl(e, defs)
+
+	var p prompter = scanner{bufio.NewScanner(e.stdin)}
+	if e.stdin == os.Stdin && liner.TerminalSupported() {
+		l := liner.NewLiner()
+		defer l.Close()
+
+		l.SetCtrlCAborts(true)
+		l.SetCompleter(r.complete)
+
+		if *history != "" {
+			if f, err := os.Open(*history); err == nil {
+				l.ReadHistory(f)
+				f.Close()
+			}
+			defer func() {
+				if f, err := os.Create(*history); err == nil {
+					l.WriteHistory(f)
+					f.Close()
+				}
+			}()
+		}
+
+		p = &session{State: l}
+		fmt.Fprintf(e.stdout, "%d definitions loaded, type help to list the commands\n", len(defs))
+	}
+
+	for {
+		line, err := p.Prompt("permission> ")
+		if err == liner.ErrPromptAborted {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = r.eval(line)
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(e.stderr, "error: %s\n", err)
+		}
+	}
+}
+
+// session reads the lines of a terminal and records them in the history
+type session struct {
+	*liner.State
+}
+
+func (s *session) Prompt(prompt string) (string, error) {
+	line, err := s.State.Prompt(prompt)
+	if err == nil && strings.TrimSpace(line) != "" {
+		s.AppendHistory(line)
+	}
+	return line, err
+}
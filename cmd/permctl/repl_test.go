@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func replSession(t *testing.T, input string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	args = append([]string{"repl", "-defs", defs, "-history", ""}, args...)
+	code = run(args, strings.NewReader(input), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRepl(t *testing.T) {
+	code, stdout, stderr := replSession(t, `
+user.edit,user.edit,playlist
+expand user,playlist.edit
+require user.email,user.about user
+union user,playlist user.edit,user
+intersect user,playlist user.edit,user
+difference user playlist,user
+defs
+nope.
+require user
+exit
+parse user
+`)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `playlist,user.edit
+playlist.edit,user.about,user.profile
+allowed
+  user.email: not granted
+  user.about: granted by user
+user,playlist,user.edit
+user
+(empty)
+user: subset edit profile email friends about, default profile about
+playlist: subset edit share read, default read share
+`, stdout)
+	assert.Equal(t, `error: nope.: The given input is not in the correct format
+error: usage: require REQUIRED SCOPE
+`, stderr)
+
+	code, stdout, _ = replSession(t, "parse user\n", "-json")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"normalized": "user"`)
+
+	code, stdout, _ = replSession(t, "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "difference A B")
+	assert.Contains(t, stdout, "exit")
+
+	code, _, stderr = permctl("repl")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "the -defs flag is required")
+}
+
+func TestReplComplete(t *testing.T) {
+	defs, err := loadDefinitions(defs)
+	require.NoError(t, err)
+
+	r := newRepl(&env{separator: ","}, defs)
+	assert.Equal(t, []string{"defs"}, r.complete("def"))
+	assert.Equal(t, []string{"require"}, r.complete("req"))
+	assert.Equal(t, []string{"expand user.edit", "expand user.email"}, r.complete("expand user.e"))
+	assert.Equal(t, []string{"union user,playlist", "union user,playlist.edit", "union user,playlist.read", "union user,playlist.share"}, r.complete("union user,pl"))
+	assert.Empty(t, r.complete("parse nope"))
+	assert.Equal(t, []string{"playlist", "playlist.edit", "playlist.read", "playlist.share"}, r.complete("pl"))
+}
@@ -0,0 +1,87 @@
+// Command permgen generates typed Go constants from a definitions file.
+//
+// Usage:
+//
+//	permgen -defs definitions.json -pkg perms -o permissions_gen.go
+//
+// It is meant to be used with go generate:
+//
+//	//go:generate go run github.com/asdine/permission/cmd/permgen -defs definitions.json -pkg perms -o permissions_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/codegen"
+)
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("permgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	defsPath := fs.String("defs", "", "path of the JSON definitions file")
+	pkg := fs.String("pkg", "", "package name of the generated file, defaults to the name of the output directory")
+	out := fs.String("o", "", "path of the generated file, defaults to the standard output")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if *defsPath == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+	if *pkg == "" && *out != "" {
+		abs, err := filepath.Abs(filepath.Dir(*out))
+		if err == nil {
+			*pkg = filepath.Base(abs)
+		}
+	}
+
+	err = generate(*defsPath, *pkg, *out, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "permgen: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func generate(defsPath, pkg, out string, stdout io.Writer) error {
+	data, err := os.ReadFile(defsPath)
+	if err != nil {
+		return err
+	}
+
+	defs, err := permission.LoadDefinitions(data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = codegen.Generate(&buf, defs, codegen.Options{Package: pkg, Source: filepath.Base(defsPath)})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = stdout.Write(buf.Bytes())
+		return err
+	}
+
+	return os.WriteFile(out, buf.Bytes(), 0644)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
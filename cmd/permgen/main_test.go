@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defs = "../../codegen/internal/example/definitions.json"
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-defs", defs, "-pkg", "example"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	golden, err := os.ReadFile("../../codegen/internal/example/permissions_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), stdout.String())
+}
+
+func TestRunOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "perms")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	out := filepath.Join(dir, "permissions_gen.go")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-defs", defs, "-o", out}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Len(t, stdout.Bytes(), 0)
+
+	src, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "package perms\n")
+}
+
+func TestRunErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "-defs")
+
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"-defs", "missing.json", "-pkg", "p"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "permgen: ")
+
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"-defs", defs, "-pkg", "bad-name"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "package name")
+}
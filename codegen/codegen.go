@@ -0,0 +1,217 @@
+// Package codegen generates Go code from definitions.
+//
+// The generated file declares a typed constant for every name and sub of the definitions,
+// the Definitions themselves and helpers, so that typos are caught by the compiler
+// instead of making Require return false at runtime.
+// The output only depends on the definitions and the options.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/asdine/permission"
+)
+
+// Errors
+var (
+	ErrBadPackage = errors.New("The package name is not a valid identifier")
+	ErrCollision  = errors.New("Two permissions have the same identifier")
+)
+
+// Options of the generator
+type Options struct {
+	// Package name of the generated file
+	Package string
+
+	// Source is the name of the definitions file, mentioned in the header
+	Source string
+}
+
+type constant struct {
+	Ident       string
+	Permission  permission.Permission
+	Description string
+}
+
+type data struct {
+	Options
+	Definitions permission.Definitions
+	Constants   []constant
+}
+
+// Generate validates the definitions and writes the generated code to w
+func Generate(w io.Writer, defs permission.Definitions, opts Options) error {
+	if !token.IsIdentifier(opts.Package) {
+		return ErrBadPackage
+	}
+
+	err := defs.Validate()
+	if err != nil {
+		return err
+	}
+
+	d := data{Options: opts, Definitions: defs}
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		consts := []constant{{Permission: permission.Permission{Name: def.Name}, Description: def.Description}}
+		for _, sub := range def.Subset {
+			consts = append(consts, constant{
+				Permission:  permission.Permission{Name: def.Name, Sub: sub},
+				Description: def.SubDescriptions[sub],
+			})
+		}
+
+		for _, c := range consts {
+			c.Ident = Identifier(c.Permission)
+			if seen[c.Ident] || reserved[c.Ident] {
+				return fmt.Errorf("%w: %s", ErrCollision, c.Ident)
+			}
+			seen[c.Ident] = true
+			d.Constants = append(d.Constants, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, &d)
+	if err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+// reserved are the identifiers declared by the generated file
+var reserved = map[string]bool{
+	"Permission":  true,
+	"Definitions": true,
+	"All":         true,
+	"Scope":       true,
+	"Require":     true,
+}
+
+// Identifier returns the exported Go identifier of the permission,
+// made of the words of its name and sub, e.g. UserEdit for user.edit or ApiKeysRead for api-keys.read
+func Identifier(p permission.Permission) string {
+	var b strings.Builder
+	for _, part := range []string{p.Name, p.Sub} {
+		words := strings.FieldsFunc(part, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			runes := []rune(word)
+			b.WriteRune(unicode.ToUpper(runes[0]))
+			b.WriteString(string(runes[1:]))
+		}
+	}
+
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "P" + ident
+	}
+	return ident
+}
+
+var tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+	// comment keeps a description on a single comment line
+	"comment": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+}).Parse(`// Code generated by permgen{{ with .Source }} from {{ . }}{{ end }}. DO NOT EDIT.
+
+package {{ .Package }}
+
+import "github.com/asdine/permission"
+
+// Permission is a defined permission
+type Permission int
+
+// Permissions
+const (
+{{- range $i, $c := .Constants }}
+	// {{ $c.Ident }} is {{ printf "%q" $c.Permission.String }}{{ with $c.Description }}: {{ comment . }}{{ end }}
+	{{ $c.Ident }}{{ if eq $i 0 }} Permission = iota{{ end }}
+{{- end }}
+)
+
+var permissions = [...]permission.Permission{
+{{- range .Constants }}
+	{{ .Ident }}: { Name: {{ printf "%q" .Permission.Name }}{{ with .Permission.Sub }}, Sub: {{ printf "%q" . }}{{ end }} },
+{{- end }}
+}
+
+// All returns every defined permission
+func All() []Permission {
+	return []Permission{
+	{{- range .Constants }}
+		{{ .Ident }},
+	{{- end }}
+	}
+}
+
+// Definitions of the permissions
+var Definitions = permission.Definitions{
+{{- range .Definitions }}
+	{
+		Name: {{ printf "%q" .Name }},
+		{{- with .Subset }}
+		Subset: []string{ {{- range $i, $s := . }}{{ if $i }}, {{ end }}{{ printf "%q" $s }}{{ end -}} },
+		{{- end }}
+		{{- with .DefaultSubset }}
+		DefaultSubset: []string{ {{- range $i, $s := . }}{{ if $i }}, {{ end }}{{ printf "%q" $s }}{{ end -}} },
+		{{- end }}
+		{{- with .Description }}
+		Description: {{ printf "%q" . }},
+		{{- end }}
+		{{- with .SubDescriptions }}
+		SubDescriptions: map[string]string{
+		{{- range $sub, $desc := . }}
+			{{ printf "%q" $sub }}: {{ printf "%q" $desc }},
+		{{- end }}
+		},
+		{{- end }}
+	},
+{{- end }}
+}
+
+// Permission returns the permission.Permission
+func (p Permission) Permission() permission.Permission {
+	return permissions[p]
+}
+
+func (p Permission) String() string {
+	return permissions[p].String()
+}
+
+// Require checks wether the scope matches the permission and is listed in the Definitions
+func (p Permission) Require(scope permission.Scope) bool {
+	return Definitions.RequireScope(permission.Scope{permissions[p]}, scope)
+}
+
+// Scope returns the scope made of the permissions
+func Scope(perms ...Permission) permission.Scope {
+	s := make(permission.Scope, len(perms))
+	for i, p := range perms {
+		s[i] = permissions[p]
+	}
+	return s
+}
+
+// Require checks wether the scope matches one of the required permissions and is listed in the Definitions
+func Require(scope permission.Scope, required ...Permission) bool {
+	return Definitions.RequireScope(Scope(required...), scope)
+}
+`))
@@ -0,0 +1,91 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the example package is the golden file, regenerate it with go generate ./...
+func TestGenerateGolden(t *testing.T) {
+	data, err := os.ReadFile("internal/example/definitions.json")
+	require.NoError(t, err)
+
+	defs, err := permission.LoadDefinitions(data)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = Generate(&buf, defs, Options{Package: "example", Source: "definitions.json"})
+	require.NoError(t, err)
+
+	golden, err := os.ReadFile("internal/example/permissions_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), buf.String())
+
+	// the output is deterministic
+	var again bytes.Buffer
+	require.NoError(t, Generate(&again, defs, Options{Package: "example", Source: "definitions.json"}))
+	assert.Equal(t, buf.String(), again.String())
+}
+
+func TestGenerateDescriptions(t *testing.T) {
+	defs := permission.Definitions{{
+		Name:            "user",
+		Subset:          []string{"edit", "read"},
+		Description:     "Access to the\nuser account",
+		SubDescriptions: map[string]string{"read": "Read the profile", "edit": "Edit the profile"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, defs, Options{Package: "p"}))
+	assert.Contains(t, buf.String(), "// User is \"user\": Access to the user account\n")
+	assert.Contains(t, buf.String(), "// UserRead is \"user.read\": Read the profile\n")
+	assert.Contains(t, buf.String(), `		Description: "Access to the\nuser account",
+		SubDescriptions: map[string]string{
+			"edit": "Edit the profile",
+			"read": "Read the profile",
+		},
+`)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	var buf bytes.Buffer
+	defs := permission.Definitions{{Name: "user"}}
+
+	assert.Equal(t, ErrBadPackage, Generate(&buf, defs, Options{Package: "my-perms"}))
+	assert.Equal(t, ErrBadPackage, Generate(&buf, defs, Options{}))
+
+	err := Generate(&buf, permission.Definitions{{Name: "user", Subset: []string{"edit"}, DefaultSubset: []string{"read"}}}, Options{Package: "p"})
+	assert.True(t, errors.Is(err, permission.ErrBadDefault))
+
+	err = Generate(&buf, permission.Definitions{{Name: "user", Subset: []string{"edit"}}, {Name: "user_edit"}}, Options{Package: "p"})
+	assert.True(t, errors.Is(err, ErrCollision))
+	assert.EqualError(t, err, "Two permissions have the same identifier: UserEdit")
+
+	err = Generate(&buf, permission.Definitions{{Name: "scope"}}, Options{Package: "p"})
+	assert.True(t, errors.Is(err, ErrCollision))
+
+	assert.Len(t, buf.Bytes(), 0)
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := map[permission.Permission]string{
+		{Name: "user"}:                        "User",
+		{Name: "user", Sub: "edit"}:           "UserEdit",
+		{Name: "api-keys", Sub: "rotate_all"}: "ApiKeysRotateAll",
+		{Name: "Billing", Sub: "readAll"}:     "BillingReadAll",
+		{Name: "2fa", Sub: "reset"}:           "P2faReset",
+		{Name: "élève", Sub: "écrire"}:        "ÉlèveÉcrire",
+		{Name: "--"}:                          "P",
+		{Name: "v1", Sub: "beta-2"}:           "V1Beta2",
+	}
+
+	for p, expected := range tests {
+		assert.Equal(t, expected, Identifier(p), p.String())
+	}
+}
@@ -0,0 +1,4 @@
+// Package example is generated from definitions.json and serves as the golden file of the generator
+package example
+
+//go:generate go run github.com/asdine/permission/cmd/permgen -defs definitions.json -o permissions_gen.go
@@ -0,0 +1,29 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerated(t *testing.T) {
+	assert.Equal(t, permission.Permission{Name: "user", Sub: "edit"}, UserEdit.Permission())
+	assert.Equal(t, "api-keys.rotate_all", ApiKeysRotateAll.String())
+	assert.Equal(t, "user", User.String())
+	assert.Len(t, All(), 14)
+	assert.NoError(t, Definitions.Validate())
+
+	s, err := permission.ParseScope("user,playlist.edit")
+	assert.NoError(t, err)
+
+	assert.True(t, UserProfile.Require(s))
+	assert.False(t, UserEdit.Require(s))
+	assert.True(t, Require(s, UserEdit, PlaylistEdit))
+	assert.False(t, Require(s, Admin))
+	assert.Equal(t, permission.Scope{{Name: "admin"}, {Name: "user", Sub: "email"}}, Scope(Admin, UserEmail))
+
+	for _, p := range All() {
+		assert.NotNil(t, Definitions.Definition(p.Permission()), p.String())
+	}
+}
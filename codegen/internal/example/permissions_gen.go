@@ -0,0 +1,126 @@
+// Code generated by permgen from definitions.json. DO NOT EDIT.
+
+package example
+
+import "github.com/asdine/permission"
+
+// Permission is a defined permission
+type Permission int
+
+// Permissions
+const (
+	// User is "user"
+	User Permission = iota
+	// UserEdit is "user.edit"
+	UserEdit
+	// UserProfile is "user.profile"
+	UserProfile
+	// UserEmail is "user.email"
+	UserEmail
+	// UserFriends is "user.friends"
+	UserFriends
+	// UserAbout is "user.about"
+	UserAbout
+	// Playlist is "playlist"
+	Playlist
+	// PlaylistEdit is "playlist.edit"
+	PlaylistEdit
+	// PlaylistShare is "playlist.share"
+	PlaylistShare
+	// PlaylistRead is "playlist.read"
+	PlaylistRead
+	// ApiKeys is "api-keys"
+	ApiKeys
+	// ApiKeysRead is "api-keys.read"
+	ApiKeysRead
+	// ApiKeysRotateAll is "api-keys.rotate_all"
+	ApiKeysRotateAll
+	// Admin is "admin"
+	Admin
+)
+
+var permissions = [...]permission.Permission{
+	User:             {Name: "user"},
+	UserEdit:         {Name: "user", Sub: "edit"},
+	UserProfile:      {Name: "user", Sub: "profile"},
+	UserEmail:        {Name: "user", Sub: "email"},
+	UserFriends:      {Name: "user", Sub: "friends"},
+	UserAbout:        {Name: "user", Sub: "about"},
+	Playlist:         {Name: "playlist"},
+	PlaylistEdit:     {Name: "playlist", Sub: "edit"},
+	PlaylistShare:    {Name: "playlist", Sub: "share"},
+	PlaylistRead:     {Name: "playlist", Sub: "read"},
+	ApiKeys:          {Name: "api-keys"},
+	ApiKeysRead:      {Name: "api-keys", Sub: "read"},
+	ApiKeysRotateAll: {Name: "api-keys", Sub: "rotate_all"},
+	Admin:            {Name: "admin"},
+}
+
+// All returns every defined permission
+func All() []Permission {
+	return []Permission{
+		User,
+		UserEdit,
+		UserProfile,
+		UserEmail,
+		UserFriends,
+		UserAbout,
+		Playlist,
+		PlaylistEdit,
+		PlaylistShare,
+		PlaylistRead,
+		ApiKeys,
+		ApiKeysRead,
+		ApiKeysRotateAll,
+		Admin,
+	}
+}
+
+// Definitions of the permissions
+var Definitions = permission.Definitions{
+	{
+		Name:          "user",
+		Subset:        []string{"edit", "profile", "email", "friends", "about"},
+		DefaultSubset: []string{"profile", "about"},
+	},
+	{
+		Name:          "playlist",
+		Subset:        []string{"edit", "share", "read"},
+		DefaultSubset: []string{"read", "share"},
+	},
+	{
+		Name:   "api-keys",
+		Subset: []string{"read", "rotate_all"},
+	},
+	{
+		Name: "admin",
+	},
+}
+
+// Permission returns the permission.Permission
+func (p Permission) Permission() permission.Permission {
+	return permissions[p]
+}
+
+func (p Permission) String() string {
+	return permissions[p].String()
+}
+
+// Require checks wether the scope matches the permission and is listed in the Definitions
+func (p Permission) Require(scope permission.Scope) bool {
+	return Definitions.RequireScope(permission.Scope{permissions[p]}, scope)
+}
+
+// Scope returns the scope made of the permissions
+func Scope(perms ...Permission) permission.Scope {
+	s := make(permission.Scope, len(perms))
+	for i, p := range perms {
+		s[i] = permissions[p]
+	}
+	return s
+}
+
+// Require checks wether the scope matches one of the required permissions and is listed in the Definitions
+func Require(scope permission.Scope, required ...Permission) bool {
+	return Definitions.RequireScope(Scope(required...), scope)
+}
@@ -0,0 +1,393 @@
+package permission
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/bits"
+	"time"
+)
+
+// fingerprintSize is the number of bytes of the fingerprint of the definitions prefixing encoded scopes
+const fingerprintSize = 4
+
+// Registry assigns an index to every name and sub of a fixed set of Definitions
+// so that scopes can be compiled to bitsets.
+// The indexes follow the order of the definitions and of their subset:
+// scopes encoded with a registry can only be decoded with a registry compiled from the same definitions
+type Registry struct {
+	defs Definitions
+
+	// fingerprint of the names, subsets and default subsets of the definitions
+	fingerprint [fingerprintSize]byte
+
+	// perms lists the permission of every index
+	perms []Permission
+	index map[Permission]int
+
+	// implied lists the indexes granted by the permission of an index:
+	// a name implies its default subset
+	implied [][]int
+}
+
+// Compile returns the Registry of the definitions
+func Compile(defs Definitions) *Registry {
+	r := Registry{
+		defs:  defs,
+		index: make(map[Permission]int),
+	}
+
+	for _, def := range defs {
+		r.add(Permission{Name: def.Name})
+		name := r.index[Permission{Name: def.Name}]
+		for _, sub := range def.Subset {
+			r.add(Permission{Name: def.Name, Sub: sub})
+		}
+
+		for _, sub := range def.DefaultSubset {
+			if i, ok := r.index[Permission{Name: def.Name, Sub: sub}]; ok {
+				r.implied[name] = append(r.implied[name], i)
+			}
+		}
+	}
+
+	h := sha256.New()
+	for _, def := range defs {
+		h.Write([]byte(def.Name))
+		for _, list := range [][]string{def.Subset, def.DefaultSubset} {
+			h.Write([]byte{0})
+			for _, sub := range list {
+				h.Write([]byte(sub))
+				h.Write([]byte{1})
+			}
+		}
+		h.Write([]byte{2})
+	}
+	copy(r.fingerprint[:], h.Sum(nil))
+
+	return &r
+}
+
+func (r *Registry) add(p Permission) {
+	if _, ok := r.index[p]; ok {
+		return
+	}
+
+	i := len(r.perms)
+	r.index[p] = i
+	r.perms = append(r.perms, p)
+	r.implied = append(r.implied, []int{i})
+}
+
+// Definitions returns the definitions of the registry
+func (r *Registry) Definitions() Definitions {
+	return r.defs
+}
+
+// Len returns the number of indexes of the registry
+func (r *Registry) Len() int {
+	return len(r.perms)
+}
+
+// Compile returns the CompiledScope of s.
+// Returns ErrUndefined if a permission is not listed in the definitions
+func (r *Registry) Compile(s Scope) (CompiledScope, error) {
+	c := r.empty()
+	for _, p := range s {
+		i, ok := r.index[p]
+		if !ok {
+			return CompiledScope{}, ErrUndefined
+		}
+
+		for _, j := range r.implied[i] {
+			c.set(j)
+		}
+	}
+	return c, nil
+}
+
+// Require compiles the scopes and checks wether the scope satisfies one of the required permissions.
+// Undefined permissions are ignored, like with Definitions.RequireScope
+func (r *Registry) Require(required, scope Scope) bool {
+	if !hooked() {
+		return r.compileDefined(scope).Satisfies(r.compileDefined(required))
+	}
+	return r.Decide(context.Background(), "", required, scope).Allowed
+}
+
+// Decide is like Definitions.Decide, using compiled scopes:
+// it evaluates the required permissions against the scope presented by the subject,
+// notifies the decision hooks and returns the Decision
+func (r *Registry) Decide(ctx context.Context, subject string, required, scope Scope) Decision {
+	start := time.Now()
+	dec := Decision{
+		Time:     start,
+		Subject:  subject,
+		Required: required,
+		Scope:    scope,
+		Reason:   ReasonUndefined,
+	}
+
+	req := r.compileDefined(required)
+	if !req.IsEmpty() {
+		dec.Reason = ReasonNotGranted
+		if r.compileDefined(scope).Satisfies(req) {
+			dec.Allowed = true
+			dec.Reason = ReasonGranted
+			for _, p := range scope {
+				if r.compileDefined(Scope{p}).Satisfies(req) {
+					dec.Match = p
+					break
+				}
+			}
+		}
+	}
+
+	dec.Duration = time.Since(start)
+	notify(ctx, dec)
+	return dec
+}
+
+func (r *Registry) compileDefined(s Scope) CompiledScope {
+	c := r.empty()
+	for _, p := range s {
+		if i, ok := r.index[p]; ok {
+			for _, j := range r.implied[i] {
+				c.set(j)
+			}
+		}
+	}
+	return c
+}
+
+// implies reports wether the permission of the index i grants the permission of the index j
+func (r *Registry) implies(i, j int) bool {
+	for _, k := range r.implied[i] {
+		if k == j {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Registry) empty() CompiledScope {
+	return CompiledScope{r: r, bits: make([]uint64, (len(r.perms)+63)/64)}
+}
+
+// Decode returns the CompiledScope encoded by CompiledScope.MarshalBinary.
+// Returns ErrMismatch if the scope was encoded by a registry compiled from other definitions,
+// for example after a default subset changed
+func (r *Registry) Decode(data []byte) (CompiledScope, error) {
+	if len(data) < fingerprintSize {
+		return CompiledScope{}, ErrBadFormat
+	}
+
+	if !bytes.Equal(data[:fingerprintSize], r.fingerprint[:]) {
+		return CompiledScope{}, ErrMismatch
+	}
+	data = data[fingerprintSize:]
+
+	explicit := r.empty()
+	if len(data) > len(explicit.bits)*8 {
+		return CompiledScope{}, ErrBadFormat
+	}
+
+	for i, b := range data {
+		explicit.bits[i/8] |= uint64(b) << (8 * (i % 8))
+	}
+
+	// reject indexes unknown to the registry
+	if n := len(r.perms) % 64; n != 0 && explicit.bits[len(explicit.bits)-1]>>n != 0 {
+		return CompiledScope{}, ErrBadFormat
+	}
+
+	// the encoding only contains the explicit permissions, names are expanded again
+	c := r.empty()
+	for i := range r.perms {
+		if explicit.isSet(i) {
+			for _, j := range r.implied[i] {
+				c.set(j)
+			}
+		}
+	}
+	return c, nil
+}
+
+// DecodeString returns the CompiledScope encoded by CompiledScope.String
+func (r *Registry) DecodeString(s string) (CompiledScope, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return CompiledScope{}, ErrBadFormat
+	}
+	return r.Decode(data)
+}
+
+// CompiledScope is a Scope compiled to a bitset by a Registry.
+// A name is compiled with its default subset, so checks are a few AND operations.
+// The zero value is an empty scope that doesn't belong to any registry
+type CompiledScope struct {
+	r    *Registry
+	bits []uint64
+}
+
+func (c CompiledScope) set(i int) {
+	c.bits[i/64] |= 1 << (i % 64)
+}
+
+func (c CompiledScope) isSet(i int) bool {
+	return i/64 < len(c.bits) && c.bits[i/64]&(1<<(i%64)) != 0
+}
+
+// Registry returns the registry that compiled the scope
+func (c CompiledScope) Registry() *Registry {
+	return c.r
+}
+
+// IsEmpty reports wether the scope has no permissions
+func (c CompiledScope) IsEmpty() bool {
+	for _, w := range c.bits {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Has reports wether the scope has the given permission, a name having its default subset
+func (c CompiledScope) Has(p Permission) bool {
+	if c.r == nil {
+		return false
+	}
+
+	i, ok := c.r.index[p]
+	return ok && c.isSet(i)
+}
+
+// Satisfies checks wether the scope satisfies one of the required permissions,
+// with the same result as Definitions.RequireScope
+func (c CompiledScope) Satisfies(required CompiledScope) bool {
+	n := len(c.bits)
+	if len(required.bits) < n {
+		n = len(required.bits)
+	}
+
+	for i := 0; i < n; i++ {
+		if c.bits[i]&required.bits[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns the permissions of c or d.
+// Both scopes must be compiled by the same registry
+func (c CompiledScope) Union(d CompiledScope) CompiledScope {
+	u := c.clone(d)
+	for i := range u.bits {
+		u.bits[i] = c.word(i) | d.word(i)
+	}
+	return u
+}
+
+// Intersect returns the permissions of c that are also in d.
+// Both scopes must be compiled by the same registry
+func (c CompiledScope) Intersect(d CompiledScope) CompiledScope {
+	u := c.clone(d)
+	for i := range u.bits {
+		u.bits[i] = c.word(i) & d.word(i)
+	}
+	return u
+}
+
+func (c CompiledScope) clone(d CompiledScope) CompiledScope {
+	r := c.r
+	if r == nil {
+		r = d.r
+	}
+	if c.r != nil && d.r != nil && c.r != d.r {
+		panic("permission: the scopes are compiled by different registries")
+	}
+	if r == nil {
+		return CompiledScope{}
+	}
+	return r.empty()
+}
+
+func (c CompiledScope) word(i int) uint64 {
+	if i < len(c.bits) {
+		return c.bits[i]
+	}
+	return 0
+}
+
+// Len returns the number of indexes set in the scope
+func (c CompiledScope) Len() int {
+	n := 0
+	for _, w := range c.bits {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Scope returns the permissions of the compiled scope, in the order of the definitions.
+// The subs of the default subset are omitted when their name is present
+func (c CompiledScope) Scope() Scope {
+	s := Scope{}
+	if c.r == nil {
+		return s
+	}
+
+	for i, p := range c.r.perms {
+		if !c.isSet(i) {
+			continue
+		}
+
+		if p.Sub != "" {
+			if name, ok := c.r.index[Permission{Name: p.Name}]; ok && c.isSet(name) && c.r.implies(name, i) {
+				continue
+			}
+		}
+		s = append(s, p)
+	}
+	return s
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The encoding starts with a fingerprint of the definitions, followed by the bitset
+// of the explicit permissions in little endian, without trailing zero bytes:
+// the default subset of a name is not encoded and is expanded when decoding.
+// The zero value encodes to nothing
+func (c CompiledScope) MarshalBinary() ([]byte, error) {
+	return c.AppendBinary(nil)
+}
+
+// AppendBinary appends the binary representation of the scope to b and returns the extended buffer
+func (c CompiledScope) AppendBinary(b []byte) ([]byte, error) {
+	if c.r == nil {
+		return b, nil
+	}
+
+	explicit := c.r.empty()
+	for _, p := range c.Scope() {
+		explicit.set(c.r.index[p])
+	}
+
+	n := len(explicit.bits) * 8
+	for n > 0 && byte(explicit.bits[(n-1)/8]>>(8*((n-1)%8))) == 0 {
+		n--
+	}
+
+	b = append(b, c.r.fingerprint[:]...)
+	for i := 0; i < n; i++ {
+		b = append(b, byte(explicit.bits[i/8]>>(8*(i%8))))
+	}
+	return b, nil
+}
+
+// String returns the binary representation of the scope encoded in unpadded URL-safe base64,
+// suitable for tokens and cookies
+func (c CompiledScope) String() string {
+	data, _ := c.MarshalBinary()
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,246 @@
+package permission
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var compileDefs = Definitions{
+	{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile", "about"}},
+	{Name: "playlist", Subset: []string{"edit", "read"}, DefaultSubset: []string{"read"}},
+	{Name: "admin"},
+}
+
+func mustCompile(t testing.TB, r *Registry, repr string) CompiledScope {
+	s, err := ParseScope(repr)
+	require.NoError(t, err)
+
+	c, err := r.Compile(s)
+	require.NoError(t, err)
+	return c
+}
+
+func TestCompile(t *testing.T) {
+	r := Compile(compileDefs)
+	assert.Equal(t, 9, r.Len())
+	assert.Equal(t, compileDefs, r.Definitions())
+
+	c := mustCompile(t, r, "user,playlist.edit")
+	assert.Equal(t, 4, c.Len())
+	assert.True(t, c.Has(Permission{Name: "user"}))
+	assert.True(t, c.Has(Permission{Name: "user", Sub: "profile"}))
+	assert.False(t, c.Has(Permission{Name: "user", Sub: "edit"}))
+	assert.True(t, c.Has(Permission{Name: "playlist", Sub: "edit"}))
+	assert.False(t, c.Has(Permission{Name: "playlist"}))
+	assert.False(t, c.Has(Permission{Name: "nope"}))
+	assert.Equal(t, r, c.Registry())
+
+	_, err := r.Compile(Scope{{Name: "user", Sub: "nope"}})
+	assert.Equal(t, ErrUndefined, err)
+
+	c, err = r.Compile(nil)
+	require.NoError(t, err)
+	assert.True(t, c.IsEmpty())
+}
+
+func TestCompiledScopeSatisfies(t *testing.T) {
+	r := Compile(compileDefs)
+
+	var all Scope
+	for _, p := range r.perms {
+		all = append(all, p)
+	}
+
+	// every pair of permissions has the same result as the slice scanning
+	for _, required := range all {
+		for _, given := range all {
+			req, err := r.Compile(Scope{required})
+			require.NoError(t, err)
+			scope, err := r.Compile(Scope{given})
+			require.NoError(t, err)
+
+			expected := compileDefs.RequireScope(Scope{required}, Scope{given})
+			assert.Equal(t, expected, scope.Satisfies(req), fmt.Sprintf("%s %s", required, given))
+			assert.Equal(t, expected, r.Require(Scope{required}, Scope{given}))
+		}
+	}
+
+	assert.True(t, mustCompile(t, r, "playlist.read,user.email").Satisfies(mustCompile(t, r, "user.edit,playlist")))
+	assert.False(t, mustCompile(t, r, "user.edit").Satisfies(mustCompile(t, r, "user")))
+	assert.False(t, CompiledScope{}.Satisfies(mustCompile(t, r, "user")))
+
+	assert.True(t, r.Require(Scope{{Name: "nope"}, {Name: "admin"}}, Scope{{Name: "admin"}, {Name: "other"}}))
+	assert.False(t, r.Require(Scope{{Name: "nope"}}, Scope{{Name: "nope"}}))
+}
+
+func TestCompiledScopeSetOperations(t *testing.T) {
+	r := Compile(compileDefs)
+	a := mustCompile(t, r, "user,playlist.edit")
+	b := mustCompile(t, r, "user.about,user.edit,admin")
+
+	assert.Equal(t, Scope{{Name: "user"}, {Name: "user", Sub: "edit"}, {Name: "playlist", Sub: "edit"}, {Name: "admin"}}, a.Union(b).Scope())
+	assert.Equal(t, Scope{{Name: "user", Sub: "about"}}, a.Intersect(b).Scope())
+	assert.Equal(t, Scope{{Name: "user"}, {Name: "playlist", Sub: "edit"}}, a.Scope())
+	assert.Equal(t, Scope{{Name: "user", Sub: "edit"}, {Name: "user", Sub: "about"}, {Name: "admin"}}, b.Scope())
+
+	assert.Equal(t, a.Scope(), a.Union(CompiledScope{}).Scope())
+	assert.True(t, CompiledScope{}.Intersect(a).IsEmpty())
+	assert.Equal(t, Scope{}, CompiledScope{}.Scope())
+
+	other := mustCompile(t, Compile(compileDefs), "user")
+	assert.Panics(t, func() { a.Union(other) })
+}
+
+func TestCompiledScopeEncoding(t *testing.T) {
+	r := Compile(compileDefs)
+	c := mustCompile(t, r, "user,playlist.edit")
+	fp := r.fingerprint[:]
+
+	// only user and playlist.edit are encoded, not the default subset of user
+	data, err := c.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, fp...), 0x41), data)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(data), c.String())
+
+	d, err := r.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, c, d)
+
+	d, err = r.DecodeString(c.String())
+	require.NoError(t, err)
+	assert.Equal(t, c, d)
+
+	// a sub of the default subset given explicitly is kept
+	c = mustCompile(t, r, "user.profile")
+	d, err = r.DecodeString(c.String())
+	require.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "user", Sub: "profile"}}, d.Scope())
+
+	empty, err := r.Compile(nil)
+	require.NoError(t, err)
+	d, err = r.DecodeString(empty.String())
+	require.NoError(t, err)
+	assert.True(t, d.IsEmpty())
+	assert.Equal(t, "", CompiledScope{}.String())
+
+	_, err = r.Decode(nil)
+	assert.Equal(t, ErrBadFormat, err)
+	_, err = r.Decode(append(append([]byte{}, fp...), 0x01, 0x02))
+	assert.Equal(t, ErrBadFormat, err)
+	_, err = r.Decode(append(append([]byte{}, fp...), make([]byte, 9)...))
+	assert.Equal(t, ErrBadFormat, err)
+	_, err = r.DecodeString("!!")
+	assert.Equal(t, ErrBadFormat, err)
+	_, err = r.Decode([]byte{0, 0, 0, 0, 0x01})
+	assert.Equal(t, ErrMismatch, err)
+
+	b, err := c.AppendBinary([]byte{0xff})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff}, b[:1])
+	assert.Equal(t, fp, b[1:5])
+}
+
+func TestDecodeExpands(t *testing.T) {
+	r := Compile(compileDefs)
+
+	// a name alone is expanded to its default subset, like with RequireScope
+	data := append(append([]byte{}, r.fingerprint[:]...), 0x01)
+	c, err := r.Decode(data)
+	require.NoError(t, err)
+	assert.True(t, c.Satisfies(mustCompile(t, r, "user.about")))
+	assert.Equal(t, compileDefs.RequireScope(Scope{{Name: "user", Sub: "about"}}, Scope{{Name: "user"}}), c.Satisfies(mustCompile(t, r, "user.about")))
+}
+
+func TestDecodeNarrowedDefaults(t *testing.T) {
+	token := mustCompile(t, Compile(compileDefs), "user").String()
+
+	narrowed := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile"}},
+		compileDefs[1],
+		compileDefs[2],
+	}
+	_, err := Compile(narrowed).DecodeString(token)
+	assert.Equal(t, ErrMismatch, err)
+
+	// descriptions don't change the encoding
+	described := append(Definitions{}, compileDefs...)
+	described[0].Description = "Access to the user account"
+	_, err = Compile(described).DecodeString(token)
+	assert.NoError(t, err)
+}
+
+func TestRegistryDecide(t *testing.T) {
+	var decisions []Decision
+	OnDecision(DecisionHookFunc(func(ctx context.Context, d Decision) {
+		decisions = append(decisions, d)
+	}))
+	defer OnDecision()
+
+	r := Compile(compileDefs)
+	d := r.Decide(context.Background(), "alice", Scope{{Name: "user", Sub: "edit"}, {Name: "user", Sub: "about"}}, Scope{{Name: "playlist"}, {Name: "user"}})
+	assert.True(t, d.Allowed)
+	assert.Equal(t, ReasonGranted, d.Reason)
+	assert.Equal(t, Permission{Name: "user"}, d.Match)
+	assert.Equal(t, "alice", d.Subject)
+
+	assert.False(t, r.Require(Scope{{Name: "user", Sub: "edit"}}, Scope{{Name: "user"}}))
+	assert.False(t, r.Require(Scope{{Name: "nope"}}, Scope{{Name: "user"}}))
+
+	require.Len(t, decisions, 3)
+	assert.Equal(t, ReasonNotGranted, decisions[1].Reason)
+	assert.Equal(t, ReasonUndefined, decisions[2].Reason)
+}
+
+func largeDefinitions(n int) Definitions {
+	defs := make(Definitions, n)
+	for i := range defs {
+		defs[i] = Definition{
+			Name:          fmt.Sprintf("api%d", i),
+			Subset:        []string{"read", "write", "delete", "admin"},
+			DefaultSubset: []string{"read"},
+		}
+	}
+	return defs
+}
+
+func TestCompileLarge(t *testing.T) {
+	defs := largeDefinitions(30)
+	r := Compile(defs)
+	assert.Equal(t, 150, r.Len())
+
+	c := mustCompile(t, r, "api0.write,api29")
+	assert.Equal(t, Scope{{Name: "api0", Sub: "write"}, {Name: "api29"}}, c.Scope())
+	assert.True(t, c.Satisfies(mustCompile(t, r, "api29.read")))
+	assert.False(t, c.Satisfies(mustCompile(t, r, "api29.write,api1")))
+
+	d, err := r.DecodeString(c.String())
+	require.NoError(t, err)
+	assert.Equal(t, c.Scope(), d.Scope())
+}
+
+func BenchmarkRequireScope(b *testing.B) {
+	defs := largeDefinitions(30)
+	required := Scope{{Name: "api29", Sub: "write"}, {Name: "api28"}}
+	scope := Scope{{Name: "api0"}, {Name: "api10", Sub: "write"}, {Name: "api20", Sub: "admin"}, {Name: "api28", Sub: "read"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		boolSink = defs.RequireScope(required, scope)
+	}
+}
+
+func BenchmarkCompiledScopeSatisfies(b *testing.B) {
+	r := Compile(largeDefinitions(30))
+	required := mustCompile(b, r, "api29.write,api28")
+	scope := mustCompile(b, r, "api0,api10.write,api20.admin,api28.read")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		boolSink = scope.Satisfies(required)
+	}
+}
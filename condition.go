@@ -0,0 +1,636 @@
+package permission
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AttributeType is the type of a request attribute
+type AttributeType int
+
+// Attribute types
+const (
+	BoolAttribute AttributeType = iota + 1
+	NumberAttribute
+	StringAttribute
+)
+
+func (t AttributeType) String() string {
+	switch t {
+	case BoolAttribute:
+		return "bool"
+	case NumberAttribute:
+		return "number"
+	case StringAttribute:
+		return "string"
+	}
+	return "invalid"
+}
+
+// Schema declares the type of every attribute a Condition can use
+type Schema map[string]AttributeType
+
+// Attributes describe a request, e.g. {"amount": 500, "repo.org": "acme"}.
+// Values must be bools, strings or numbers
+type Attributes map[string]interface{}
+
+// ConditionError is returned when a Condition cannot be parsed, type checked or evaluated
+type ConditionError struct {
+	// Pos is the byte offset of the error in the condition
+	Pos int
+	Msg string
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("condition: %s at offset %d", e.Msg, e.Pos)
+}
+
+// Condition is a boolean expression over request attributes.
+//
+// The language supports bool, number and string literals, attribute names,
+// the comparison operators == != < <= > >= and the logical operators && || !.
+// For example:
+//
+//	amount < 10000 && currency == "EUR"
+//	repo.org == caller.org || caller.admin
+//
+// A Condition has no side effect and its evaluation time is linear to its size.
+// Parentheses and ! can be nested up to MaxConditionDepth levels
+type Condition struct {
+	src  string
+	root node
+}
+
+// ParseCondition parses the given expression
+func ParseCondition(src string) (*Condition, error) {
+	p := condParser{lex: condLexer{src: src}}
+	p.next()
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	return &Condition{src: src, root: root}, nil
+}
+
+// String returns the source of the condition
+func (c *Condition) String() string {
+	return c.src
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (c *Condition) MarshalText() ([]byte, error) {
+	return []byte(c.src), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (c *Condition) UnmarshalText(text []byte) error {
+	parsed, err := ParseCondition(string(text))
+	if err != nil {
+		return err
+	}
+
+	*c = *parsed
+	return nil
+}
+
+// Check verifies that the condition only uses attributes of the schema,
+// that operands have compatible types and that the condition is a bool expression
+func (c *Condition) Check(schema Schema) error {
+	t, err := c.root.check(schema)
+	if err != nil {
+		return err
+	}
+
+	if t != BoolAttribute {
+		return &ConditionError{Pos: c.root.pos(), Msg: fmt.Sprintf("condition is a %s, not a bool", t)}
+	}
+
+	return nil
+}
+
+// Eval evaluates the condition against the attributes.
+// It returns an error if an attribute is missing or has an unexpected type
+func (c *Condition) Eval(attrs Attributes) (bool, error) {
+	v, err := c.root.eval(attrs)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, &ConditionError{Pos: c.root.pos(), Msg: "condition is not a bool"}
+	}
+
+	return b, nil
+}
+
+type node interface {
+	pos() int
+	check(Schema) (AttributeType, error)
+	eval(Attributes) (interface{}, error)
+}
+
+type literal struct {
+	at    int
+	value interface{}
+}
+
+func (l *literal) pos() int {
+	return l.at
+}
+
+func (l *literal) check(Schema) (AttributeType, error) {
+	return typeOf(l.value), nil
+}
+
+func (l *literal) eval(Attributes) (interface{}, error) {
+	return l.value, nil
+}
+
+type attribute struct {
+	at   int
+	name string
+}
+
+func (a *attribute) pos() int {
+	return a.at
+}
+
+func (a *attribute) check(schema Schema) (AttributeType, error) {
+	t, ok := schema[a.name]
+	if !ok {
+		return 0, &ConditionError{Pos: a.at, Msg: fmt.Sprintf("unknown attribute %q", a.name)}
+	}
+	return t, nil
+}
+
+func (a *attribute) eval(attrs Attributes) (interface{}, error) {
+	v, ok := attrs[a.name]
+	if !ok {
+		return nil, &ConditionError{Pos: a.at, Msg: fmt.Sprintf("missing attribute %q", a.name)}
+	}
+
+	v = normalizeValue(v)
+	if typeOf(v) == 0 {
+		return nil, &ConditionError{Pos: a.at, Msg: fmt.Sprintf("unsupported type %T for attribute %q", v, a.name)}
+	}
+	return v, nil
+}
+
+type unary struct {
+	at      int
+	operand node
+}
+
+func (u *unary) pos() int {
+	return u.at
+}
+
+func (u *unary) check(schema Schema) (AttributeType, error) {
+	t, err := u.operand.check(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	if t != BoolAttribute {
+		return 0, &ConditionError{Pos: u.at, Msg: fmt.Sprintf("operator ! expects a bool, got a %s", t)}
+	}
+	return BoolAttribute, nil
+}
+
+func (u *unary) eval(attrs Attributes) (interface{}, error) {
+	v, err := u.operand.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return nil, &ConditionError{Pos: u.at, Msg: "operator ! expects a bool"}
+	}
+	return !b, nil
+}
+
+type binary struct {
+	at          int
+	op          string
+	left, right node
+}
+
+func (b *binary) pos() int {
+	return b.at
+}
+
+func (b *binary) check(schema Schema) (AttributeType, error) {
+	lt, err := b.left.check(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	rt, err := b.right.check(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case "&&", "||":
+		if lt != BoolAttribute || rt != BoolAttribute {
+			return 0, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("operator %s expects bools, got a %s and a %s", b.op, lt, rt)}
+		}
+	case "==", "!=":
+		if lt != rt {
+			return 0, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("cannot compare a %s with a %s", lt, rt)}
+		}
+	default:
+		if lt != rt || lt == BoolAttribute {
+			return 0, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("operator %s cannot compare a %s with a %s", b.op, lt, rt)}
+		}
+	}
+
+	return BoolAttribute, nil
+}
+
+func (b *binary) eval(attrs Attributes) (interface{}, error) {
+	l, err := b.left.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || short-circuit
+	if lb, ok := l.(bool); ok && (b.op == "&&" && !lb || b.op == "||" && lb) {
+		return lb, nil
+	}
+
+	r, err := b.right.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if typeOf(l) != typeOf(r) {
+		return nil, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("cannot compare a %s with a %s", typeOf(l), typeOf(r))}
+	}
+
+	switch b.op {
+	case "&&", "||":
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("operator %s expects bools", b.op)}
+		}
+		return rb, nil
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	var cmp int
+	switch lv := l.(type) {
+	case float64:
+		rv := r.(float64)
+		switch {
+		case lv < rv:
+			cmp = -1
+		case lv > rv:
+			cmp = 1
+		}
+	case string:
+		cmp = strings.Compare(lv, r.(string))
+	default:
+		return nil, &ConditionError{Pos: b.at, Msg: fmt.Sprintf("operator %s cannot compare bools", b.op)}
+	}
+
+	switch b.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+func typeOf(v interface{}) AttributeType {
+	switch v.(type) {
+	case bool:
+		return BoolAttribute
+	case float64:
+		return NumberAttribute
+	case string:
+		return StringAttribute
+	}
+	return 0
+}
+
+// normalizeValue converts all numbers to float64
+func normalizeValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int8:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case uint16:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokError
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+type condLexer struct {
+	src string
+	pos int
+}
+
+func (l *condLexer) next() token {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+
+	start := l.pos
+	if l.pos == len(l.src) {
+		return token{kind: tokEOF, pos: start}
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, pos: start, text: "("}
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, pos: start, text: ")"}
+	case c == '"' || c == '\'':
+		return l.lexString(c)
+	case isDigit(c):
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokNumber, pos: start, text: l.src[start:l.pos]}
+	case isIdentStart(c):
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokIdent, pos: start, text: l.src[start:l.pos]}
+	}
+
+	for _, op := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "-"} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, pos: start, text: op}
+		}
+	}
+
+	l.pos++
+	return token{kind: tokError, pos: start, text: l.src[start:l.pos]}
+}
+
+func (l *condLexer) lexString(quote byte) token {
+	start := l.pos
+	l.pos++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case quote:
+			return token{kind: tokString, pos: start, text: b.String()}
+		case '\\':
+			if l.pos == len(l.src) {
+				break
+			}
+			c = l.src[l.pos]
+			l.pos++
+		}
+		b.WriteByte(c)
+	}
+
+	return token{kind: tokError, pos: start, text: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// MaxConditionDepth is the maximum nesting of parentheses and ! operators in a Condition,
+// so that parsing and evaluating untrusted conditions can't exhaust the stack
+const MaxConditionDepth = 64
+
+type condParser struct {
+	lex condLexer
+	tok token
+
+	// depth of the parentheses and ! operators being parsed
+	depth int
+}
+
+func (p *condParser) next() {
+	p.tok = p.lex.next()
+}
+
+func (p *condParser) errorf(format string, args ...interface{}) error {
+	return &ConditionError{Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// enter increments the nesting depth, it must be followed by a call to leave
+func (p *condParser) enter() error {
+	p.depth++
+	if p.depth > MaxConditionDepth {
+		return p.errorf("nesting deeper than %d levels", MaxConditionDepth)
+	}
+	return nil
+}
+
+func (p *condParser) leave() {
+	p.depth--
+}
+
+func (p *condParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokOp && p.tok.text == "||" {
+		at := p.tok.pos
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{at: at, op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokOp && p.tok.text == "&&" {
+		at := p.tok.pos
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{at: at, op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *condParser) parseNot() (node, error) {
+	if p.tok.kind == tokOp && p.tok.text == "!" {
+		err := p.enter()
+		defer p.leave()
+		if err != nil {
+			return nil, err
+		}
+
+		at := p.tok.pos
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{at: at, operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *condParser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokOp {
+		return left, nil
+	}
+
+	switch p.tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+
+	at, op := p.tok.pos, p.tok.text
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &binary{at: at, op: op, left: left, right: right}, nil
+}
+
+func (p *condParser) parseOperand() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokLParen:
+		err := p.enter()
+		defer p.leave()
+		if err != nil {
+			return nil, err
+		}
+
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return n, nil
+	case tokNumber:
+		return p.parseNumber(tok.pos, tok.text)
+	case tokString:
+		p.next()
+		return &literal{at: tok.pos, value: tok.text}, nil
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true":
+			return &literal{at: tok.pos, value: true}, nil
+		case "false":
+			return &literal{at: tok.pos, value: false}, nil
+		}
+		return &attribute{at: tok.pos, name: tok.text}, nil
+	case tokOp:
+		if tok.text == "-" {
+			p.next()
+			if p.tok.kind != tokNumber {
+				return nil, p.errorf("expected a number")
+			}
+			return p.parseNumber(tok.pos, "-"+p.tok.text)
+		}
+	case tokEOF:
+		return nil, p.errorf("unexpected end of condition")
+	case tokError:
+		return nil, p.errorf("invalid token %q", tok.text)
+	}
+
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func (p *condParser) parseNumber(pos int, text string) (node, error) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, &ConditionError{Pos: pos, Msg: fmt.Sprintf("invalid number %q", text)}
+	}
+
+	p.next()
+	return &literal{at: pos, value: f}, nil
+}
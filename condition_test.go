@@ -0,0 +1,186 @@
+package permission
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	"amount":       NumberAttribute,
+	"currency":     StringAttribute,
+	"repo.org":     StringAttribute,
+	"caller.org":   StringAttribute,
+	"caller.admin": BoolAttribute,
+}
+
+func TestParseCondition(t *testing.T) {
+	valid := []string{
+		"amount < 10000",
+		"amount<10000&&currency=='EUR'",
+		`repo.org == caller.org || caller.admin`,
+		`!(amount >= -1.5) && !caller.admin`,
+		`currency != "U\"S"`,
+		"true",
+	}
+
+	for _, src := range valid {
+		c, err := ParseCondition(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, src, c.String())
+	}
+
+	invalid := map[string]int{
+		"":                 0,
+		"amount <":         8,
+		"amount < 1 2":     11,
+		"(amount < 1":      11,
+		"amount < 1.2.3":   9,
+		"amount # 1":       7,
+		"currency == 'EUR": 12,
+		"- amount":         2,
+		"amount < 1 ||":    13,
+	}
+
+	for src, pos := range invalid {
+		_, err := ParseCondition(src)
+		require.Error(t, err, src)
+		assert.IsType(t, &ConditionError{}, err, src)
+		assert.Equal(t, pos, err.(*ConditionError).Pos, src)
+	}
+}
+
+func TestParseConditionDepth(t *testing.T) {
+	nested := strings.Repeat("(", MaxConditionDepth) + "true" + strings.Repeat(")", MaxConditionDepth)
+	_, err := ParseCondition(nested)
+	require.NoError(t, err)
+
+	_, err = ParseCondition(strings.Repeat("!", MaxConditionDepth) + "true")
+	require.NoError(t, err)
+
+	// the depth is released when a nested expression ends
+	_, err = ParseCondition(strings.Repeat(nested+" && ", 10) + nested)
+	require.NoError(t, err)
+
+	for _, src := range []string{
+		"(" + nested + ")",
+		"!" + strings.Repeat("!", MaxConditionDepth) + "true",
+		strings.Repeat("(!", MaxConditionDepth),
+		strings.Repeat("(", 1000000),
+		strings.Repeat("!", 1000000),
+	} {
+		_, err := ParseCondition(src)
+		require.Error(t, err)
+		assert.IsType(t, &ConditionError{}, err)
+		assert.Contains(t, err.Error(), "nesting deeper than 64 levels")
+	}
+}
+
+func TestConditionCheck(t *testing.T) {
+	valid := []string{
+		"amount < 10000",
+		"currency >= 'A' && amount == 3",
+		"repo.org == caller.org || caller.admin",
+		"!caller.admin",
+		"caller.admin == false",
+	}
+
+	for _, src := range valid {
+		c, err := ParseCondition(src)
+		require.NoError(t, err, src)
+		assert.NoError(t, c.Check(testSchema), src)
+	}
+
+	invalid := []string{
+		"amount",
+		"owner == 'bob'",
+		"amount < '10'",
+		"caller.admin < true",
+		"amount && caller.admin",
+		"!amount",
+		"currency == 3 || caller.admin",
+		"'a'",
+	}
+
+	for _, src := range invalid {
+		c, err := ParseCondition(src)
+		require.NoError(t, err, src)
+		err = c.Check(testSchema)
+		assert.Error(t, err, src)
+		assert.IsType(t, &ConditionError{}, err, src)
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	attrs := Attributes{
+		"amount":       500,
+		"currency":     "EUR",
+		"repo.org":     "acme",
+		"caller.org":   "acme",
+		"caller.admin": false,
+	}
+
+	tests := map[string]bool{
+		"amount < 10000":                         true,
+		"amount > 500":                           false,
+		"amount >= 500 && amount <= 500.0":       true,
+		"currency == 'EUR'":                      true,
+		"currency < 'USD'":                       true,
+		"repo.org == caller.org":                 true,
+		"repo.org != caller.org || caller.admin": false,
+		"!caller.admin":                          true,
+		"!(amount < 1 || currency == 'EUR')":     false,
+		"caller.admin && owner == 'bob'":         false,
+		"amount < 1 && owner == 'bob'":           false,
+		"amount > 1 || owner == 'bob'":           true,
+	}
+
+	for src, expected := range tests {
+		c, err := ParseCondition(src)
+		require.NoError(t, err, src)
+		ok, err := c.Eval(attrs)
+		require.NoError(t, err, src)
+		assert.Equal(t, expected, ok, src)
+	}
+
+	errs := map[string]Attributes{
+		"owner == 'bob'":  attrs,
+		"amount < 10":     {"amount": "10"},
+		"amount == 10":    {"amount": []int{10}},
+		"amount":          attrs,
+		"caller.admin":    {"caller.admin": 1},
+		"!amount":         attrs,
+		"amount && true":  {"amount": 1},
+		"true && amount":  {"amount": 1},
+		"amount < amount": {"amount": true},
+	}
+
+	for src, attrs := range errs {
+		c, err := ParseCondition(src)
+		require.NoError(t, err, src)
+		_, err = c.Eval(attrs)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestConditionJSON(t *testing.T) {
+	var v struct {
+		Condition *Condition
+	}
+
+	err := json.Unmarshal([]byte(`{"Condition":"amount < 10"}`), &v)
+	require.NoError(t, err)
+	ok, err := v.Condition.Eval(Attributes{"amount": 5})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Condition":"amount \u003c 10"}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"Condition":"amount <"}`), &v)
+	assert.Error(t, err)
+}
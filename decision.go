@@ -0,0 +1,176 @@
+package permission
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reason explains the outcome of a Decision
+type Reason string
+
+// Reasons
+const (
+	// ReasonGranted means a presented permission satisfies a required permission
+	ReasonGranted Reason = "granted"
+
+	// ReasonNotGranted means no presented permission satisfies the required permissions
+	ReasonNotGranted Reason = "not granted"
+
+	// ReasonUndefined means none of the required permissions is listed in the definitions
+	ReasonUndefined Reason = "undefined"
+
+	// ReasonError means the evaluation failed, for example because the input couldn't be parsed
+	ReasonError Reason = "error"
+)
+
+// Decision describes an evaluation of required permissions against a scope
+type Decision struct {
+	Time time.Time
+
+	// Subject and Tenant are empty if the evaluation doesn't know them
+	Subject string
+	Tenant  string
+
+	Required Scope
+
+	// Scope presented by the subject
+	Scope Scope
+
+	Allowed bool
+	Reason  Reason
+
+	// Match is the presented permission that satisfied the requirement, if allowed
+	Match Permission
+
+	// Err is set if the Reason is ReasonError
+	Err error
+
+	// Duration of the evaluation
+	Duration time.Duration
+}
+
+// DecisionHook is notified of every decision.
+// Hooks are called synchronously and must not modify the scopes of the Decision
+type DecisionHook interface {
+	HandleDecision(ctx context.Context, d Decision)
+}
+
+// DecisionHookFunc is a function used as a DecisionHook
+type DecisionHookFunc func(ctx context.Context, d Decision)
+
+// HandleDecision calls fn
+func (fn DecisionHookFunc) HandleDecision(ctx context.Context, d Decision) {
+	fn(ctx, d)
+}
+
+var hooks []DecisionHook
+
+var hooksLock sync.RWMutex
+
+// hookCount is the number of hooks, read without locking so that evaluations
+// skip the timing and the notification when there are none
+var hookCount atomic.Int32
+
+// OnDecision is a thread-safe function that sets the hooks notified of every decision
+// made by the Require functions. Calling it without hooks removes them.
+// Defaults to no hooks
+func OnDecision(h ...DecisionHook) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	hooks = h
+	hookCount.Store(int32(len(h)))
+}
+
+// hooked reports wether hooks are registered
+func hooked() bool {
+	return hookCount.Load() > 0
+}
+
+func notify(ctx context.Context, d Decision) {
+	if !hooked() {
+		return
+	}
+
+	hooksLock.RLock()
+	h := hooks
+	hooksLock.RUnlock()
+
+	for _, hook := range h {
+		hook.HandleDecision(ctx, d)
+	}
+}
+
+// Decide evaluates the required permissions against the scope presented by the subject,
+// notifies the decision hooks and returns the Decision.
+// The subject can be empty
+func (d Definitions) Decide(ctx context.Context, subject string, required, scope Scope) Decision {
+	return d.decideFor(ctx, "", subject, required, scope)
+}
+
+func (d Definitions) decideFor(ctx context.Context, tenant, subject string, required, scope Scope) Decision {
+	start := time.Now()
+	dec := d.decide(required, scope)
+	dec.Time = start
+	dec.Duration = time.Since(start)
+	dec.Subject = subject
+	dec.Tenant = tenant
+	notify(ctx, dec)
+	return dec
+}
+
+// decide evaluates the required permissions against the scope, without timing the evaluation
+func (d Definitions) decide(required, scope Scope) Decision {
+	dec := Decision{
+		Required: required,
+		Scope:    scope,
+		Reason:   ReasonUndefined,
+	}
+
+	for _, perm := range required {
+		def := d.Definition(perm)
+		if def == nil {
+			continue
+		}
+
+		dec.Reason = ReasonNotGranted
+		for _, p := range scope {
+			if def.Allowed(perm, p) {
+				dec.Allowed = true
+				dec.Reason = ReasonGranted
+				dec.Match = p
+				return dec
+			}
+		}
+	}
+
+	return dec
+}
+
+// Notify notifies the decision hooks of a decision made outside of the Require functions,
+// for example served from a cache
+func Notify(ctx context.Context, d Decision) {
+	notify(ctx, d)
+}
+
+// Fail notifies the decision hooks that an evaluation failed with err, for example
+// because the input couldn't be parsed, and returns the Decision.
+// It lets packages wrapping the Require functions report their failures like them.
+// The subject can be empty
+func Fail(ctx context.Context, subject string, err error) Decision {
+	return fail(ctx, "", subject, err)
+}
+
+// fail notifies the decision hooks that an evaluation failed
+func fail(ctx context.Context, tenant, subject string, err error) Decision {
+	dec := Decision{
+		Time:    time.Now(),
+		Subject: subject,
+		Tenant:  tenant,
+		Reason:  ReasonError,
+		Err:     err,
+	}
+	notify(ctx, dec)
+	return dec
+}
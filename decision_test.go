@@ -0,0 +1,130 @@
+package permission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	decisions []Decision
+}
+
+func (r *recorder) HandleDecision(ctx context.Context, d Decision) {
+	r.decisions = append(r.decisions, d)
+}
+
+func (r *recorder) last() Decision {
+	return r.decisions[len(r.decisions)-1]
+}
+
+func TestDecide(t *testing.T) {
+	r := new(recorder)
+	OnDecision(r)
+	defer OnDecision()
+
+	def := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+	}
+
+	assert.True(t, def.Require("user.profile", "playlist,user"))
+	require.Len(t, r.decisions, 1)
+	d := r.last()
+	assert.True(t, d.Allowed)
+	assert.Equal(t, ReasonGranted, d.Reason)
+	assert.Equal(t, Permission{Name: "user"}, d.Match)
+	assert.Equal(t, Scope{{Name: "user", Sub: "profile"}}, d.Required)
+	assert.Equal(t, Scope{{Name: "playlist"}, {Name: "user"}}, d.Scope)
+	assert.False(t, d.Time.IsZero())
+	assert.Empty(t, d.Subject)
+
+	assert.False(t, def.Require("user.edit", "user"))
+	assert.Equal(t, ReasonNotGranted, r.last().Reason)
+	assert.True(t, r.last().Match.IsZero())
+
+	assert.False(t, def.Require("playlist.edit", "playlist.edit"))
+	assert.Equal(t, ReasonUndefined, r.last().Reason)
+
+	assert.False(t, def.Require("user.", "user"))
+	assert.Equal(t, ReasonError, r.last().Reason)
+	assert.Equal(t, ErrBadFormat, r.last().Err)
+
+	d = def.Decide(context.Background(), "alice", Scope{{Name: "user", Sub: "edit"}}, Scope{{Name: "user", Sub: "edit"}})
+	assert.True(t, d.Allowed)
+	assert.Equal(t, "alice", d.Subject)
+	assert.Equal(t, d, r.last())
+	assert.Len(t, r.decisions, 5)
+
+	OnDecision()
+	def.Require("user.edit", "user.edit")
+	assert.Len(t, r.decisions, 5)
+}
+
+func TestDecideSubjects(t *testing.T) {
+	r := new(recorder)
+	OnDecision(r, DecisionHookFunc(func(ctx context.Context, d Decision) {}))
+	defer OnDecision()
+
+	ctx := context.Background()
+	def := Definitions{{Name: "repo", Subset: []string{"write"}}}
+
+	g := NewGroups()
+	require.NoError(t, g.Grant("backend", Scope{{Name: "repo", Sub: "write"}}))
+	require.NoError(t, g.AddMember("backend", "alice"))
+	assert.True(t, def.RequireGroups("repo.write", g, "alice", nil))
+	assert.Equal(t, "alice", r.last().Subject)
+
+	m := NewMultiTenant(NewMemoryStore(), def, nil)
+	ok, err := m.Require(ctx, "acme", "bob", "repo.write")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "bob", r.last().Subject)
+	assert.Equal(t, "acme", r.last().Tenant)
+	assert.Equal(t, ReasonNotGranted, r.last().Reason)
+
+	_, err = m.Require(ctx, "a/b", "bob", "repo.write")
+	assert.Equal(t, ErrBadFormat, err)
+	assert.Equal(t, ReasonError, r.last().Reason)
+	assert.Equal(t, "a/b", r.last().Tenant)
+}
+
+func TestFail(t *testing.T) {
+	r := new(recorder)
+	OnDecision(r)
+	defer OnDecision()
+
+	d := Fail(context.Background(), "alice", ErrBadFormat)
+	require.Len(t, r.decisions, 1)
+	assert.Equal(t, d, r.last())
+	assert.Equal(t, "alice", d.Subject)
+	assert.Equal(t, ReasonError, d.Reason)
+	assert.Equal(t, ErrBadFormat, d.Err)
+	assert.False(t, d.Allowed)
+	assert.False(t, d.Time.IsZero())
+}
+
+func TestRequireWithoutHooks(t *testing.T) {
+	OnDecision()
+
+	def := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+	}
+	required := Scope{{Name: "user", Sub: "profile"}}
+	scope := Scope{{Name: "playlist"}, {Name: "user"}}
+	r := Compile(def)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		def.RequireScope(required, scope)
+	})
+	assert.Zero(t, allocs)
+	assert.True(t, def.RequireScope(required, scope))
+	assert.True(t, r.Require(required, scope))
+	assert.False(t, r.Require(Scope{{Name: "user", Sub: "edit"}}, scope))
+
+	// explicit decisions are still timed
+	d := def.Decide(context.Background(), "", required, scope)
+	assert.False(t, d.Time.IsZero())
+	assert.Equal(t, Permission{Name: "user"}, d.Match)
+}
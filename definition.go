@@ -1,5 +1,11 @@
 package permission
 
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
 // Definition defines a Permission and its subset.
 // It allows to explicitly define the rules of a permission and to test permissions against the definition.
 type Definition struct {
@@ -11,6 +17,12 @@ type Definition struct {
 
 	// DefaultSubset is a list of sub permissions allowed when only the name of the permission is specified
 	DefaultSubset []string
+
+	// Description of the permission, used in documentation
+	Description string
+
+	// SubDescriptions maps sub permissions to their description
+	SubDescriptions map[string]string
 }
 
 // Match detects if the given permission matches the Definition
@@ -54,26 +66,25 @@ type Definitions []Definition
 func (d Definitions) Require(required, scope string) bool {
 	req, err := ParseScope(required)
 	if err != nil {
+		fail(context.Background(), "", "", err)
 		return false
 	}
 
 	s, err := ParseScope(scope)
 	if err != nil {
+		fail(context.Background(), "", "", err)
 		return false
 	}
 
-	for _, perm := range req {
-		def := d.Definition(perm)
-		if def != nil {
-			for _, p := range s {
-				if def.Allowed(perm, p) {
-					return true
-				}
-			}
-		}
-	}
+	return d.RequireScope(req, s)
+}
 
-	return false
+// RequireScope checks wether the given scope matches one of the required permissions and is listed in the definitions.
+func (d Definitions) RequireScope(required, scope Scope) bool {
+	if !hooked() {
+		return d.decide(required, scope).Allowed
+	}
+	return d.Decide(context.Background(), "", required, scope).Allowed
 }
 
 // Definition returns the Definition that matches the Permission
@@ -85,3 +96,116 @@ func (d Definitions) Definition(p Permission) *Definition {
 	}
 	return nil
 }
+
+// DefinitionError is returned when a definition is not valid
+type DefinitionError struct {
+	// Index of the definition in the Definitions
+	Index int
+	Name  string
+	Err   error
+}
+
+func (e *DefinitionError) Error() string {
+	return fmt.Sprintf("definition %d (%s): %s", e.Index, e.Name, e.Err)
+}
+
+// Unwrap returns the error of the definition
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that every definition has a unique name that can be parsed back,
+// unique subs, and a default subset and sub descriptions included in its subset
+func (d Definitions) Validate() error {
+	names := make(map[string]bool, len(d))
+	for i, def := range d {
+		err := def.validate(names)
+		if err != nil {
+			return &DefinitionError{Index: i, Name: def.Name, Err: err}
+		}
+		names[def.Name] = true
+	}
+	return nil
+}
+
+func (def *Definition) validate(names map[string]bool) error {
+	if def.Name == "" {
+		return ErrEmptyName
+	}
+
+	if names[def.Name] {
+		return ErrDuplicate
+	}
+
+	if !parsesBack(Permission{Name: def.Name}) {
+		return ErrBadFormat
+	}
+
+	subs := make(map[string]bool, len(def.Subset))
+	for _, sub := range def.Subset {
+		if sub == "" || !parsesBack(Permission{Name: def.Name, Sub: sub}) {
+			return ErrBadFormat
+		}
+
+		if subs[sub] {
+			return ErrDuplicate
+		}
+		subs[sub] = true
+	}
+
+	for _, sub := range def.DefaultSubset {
+		if !subs[sub] {
+			return ErrBadDefault
+		}
+	}
+
+	for sub := range def.SubDescriptions {
+		if !subs[sub] {
+			return ErrUnknownSub
+		}
+	}
+
+	return nil
+}
+
+// parsesBack reports whether the permission is unchanged when written in a scope and parsed again
+func parsesBack(p Permission) bool {
+	s, err := ParseScope(p.String())
+	return err == nil && len(s) == 1 && s[0].Equal(p)
+}
+
+// LoadDefinitions decodes a JSON list of definitions and validates them.
+// Field names are case insensitive
+func LoadDefinitions(data []byte) (Definitions, error) {
+	var d Definitions
+	err := json.Unmarshal(data, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Expand returns the normalized scope where the permissions only specified by their name
+// are replaced by the default subset of their definition.
+// Permissions without definition or default subset are kept as is
+func (d Definitions) Expand(s Scope) Scope {
+	var expanded Scope
+	for _, p := range s {
+		def := d.Definition(p)
+		if p.Sub != "" || def == nil || len(def.DefaultSubset) == 0 {
+			expanded = append(expanded, p)
+			continue
+		}
+
+		for _, sub := range def.DefaultSubset {
+			expanded = append(expanded, Permission{Name: p.Name, Sub: sub})
+		}
+	}
+	return expanded.Normalize()
+}
@@ -149,3 +149,84 @@ func TestDefinitions_Require(t *testing.T) {
 	assert.False(t, d.Require("a.", "a,b.i"))
 	assert.False(t, d.Require("a", "a,"))
 }
+
+func TestDefinitions_RequireScope(t *testing.T) {
+	d := Definitions{
+		{
+			Name:          "a",
+			Subset:        []string{"i", "j", "k"},
+			DefaultSubset: []string{"i", "j"},
+		},
+	}
+
+	assert.True(t, d.RequireScope(Scope{{Name: "a", Sub: "i"}}, Scope{{Name: "a"}}))
+	assert.False(t, d.RequireScope(Scope{{Name: "a", Sub: "k"}}, Scope{{Name: "a"}}))
+	assert.False(t, d.RequireScope(Scope{{Name: "b"}}, Scope{{Name: "b"}}))
+	assert.False(t, d.RequireScope(nil, Scope{{Name: "a"}}))
+	assert.False(t, d.RequireScope(Scope{{Name: "a"}}, nil))
+}
+
+func TestDefinitions_Validate(t *testing.T) {
+	valid := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+		{Name: "admin"},
+	}
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		defs  Definitions
+		index int
+		err   error
+	}{
+		{Definitions{{Name: "user"}, {}}, 1, ErrEmptyName},
+		{Definitions{{Name: "user"}, {Name: "user"}}, 1, ErrDuplicate},
+		{Definitions{{Name: "user.edit"}}, 0, ErrBadFormat},
+		{Definitions{{Name: "user,admin"}}, 0, ErrBadFormat},
+		{Definitions{{Name: "user", Subset: []string{"a.b"}}}, 0, ErrBadFormat},
+		{Definitions{{Name: "user", Subset: []string{""}}}, 0, ErrBadFormat},
+		{Definitions{{Name: "user", Subset: []string{"edit", "edit"}}}, 0, ErrDuplicate},
+		{Definitions{{Name: "user", Subset: []string{"edit"}, DefaultSubset: []string{"profile"}}}, 0, ErrBadDefault},
+		{Definitions{{Name: "user", Subset: []string{"edit"}, SubDescriptions: map[string]string{"read": "Read"}}}, 0, ErrUnknownSub},
+	}
+
+	for _, test := range tests {
+		err := test.defs.Validate()
+		assert.Equal(t, &DefinitionError{Index: test.index, Name: test.defs[test.index].Name, Err: test.err}, err)
+	}
+
+	assert.Equal(t, "definition 1 (user): The name is declared twice", tests[1].defs.Validate().Error())
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	defs, err := LoadDefinitions([]byte(`[
+		{"name": "user", "subset": ["edit", "profile"], "defaultSubset": ["profile"]},
+		{"Name": "admin", "description": "Administration", "subDescriptions": {}}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, Definitions{
+		{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+		{Name: "admin", Description: "Administration", SubDescriptions: map[string]string{}},
+	}, defs)
+
+	_, err = LoadDefinitions([]byte(`[{"name": "user"}, {"name": "user"}]`))
+	assert.Error(t, err)
+
+	_, err = LoadDefinitions([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestDefinitions_Expand(t *testing.T) {
+	defs := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "about"}, DefaultSubset: []string{"profile", "about"}},
+		{Name: "admin"},
+	}
+
+	s := Scope{{Name: "user"}, {Name: "user", Sub: "edit"}, {Name: "admin"}, {Name: "repo"}, {Name: "user", Sub: "about"}}
+	assert.Equal(t, Scope{
+		{Name: "admin"},
+		{Name: "repo"},
+		{Name: "user", Sub: "about"},
+		{Name: "user", Sub: "edit"},
+		{Name: "user", Sub: "profile"},
+	}, defs.Expand(s))
+}
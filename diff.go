@@ -0,0 +1,173 @@
+package permission
+
+import "sort"
+
+// ChangeKind classifies a change between two versions of definitions
+// by its effect on the existing scopes
+type ChangeKind int
+
+// Change kinds, from the least to the most disruptive
+const (
+	// Additive changes don't remove access from any scope
+	Additive ChangeKind = iota
+
+	// Narrowing changes remove access granted implicitly, by a permission only specified by its name
+	Narrowing
+
+	// Breaking changes remove access granted explicitly
+	Breaking
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Additive:
+		return "additive"
+	case Narrowing:
+		return "narrowing"
+	}
+	return "breaking"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (k *ChangeKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "additive":
+		*k = Additive
+	case "narrowing":
+		*k = Narrowing
+	case "breaking":
+		*k = Breaking
+	default:
+		return ErrBadFormat
+	}
+	return nil
+}
+
+// Change between two versions of a definition
+type Change struct {
+	Kind ChangeKind
+
+	// Name of the definition
+	Name string
+
+	// Sub concerned by the change, empty if the whole definition is added or removed
+	Sub string
+
+	Description string
+}
+
+func (c Change) String() string {
+	p := Permission{Name: c.Name, Sub: c.Sub}
+	return c.Kind.String() + ": " + p.String() + ": " + c.Description
+}
+
+// Changes are a list of Change
+type Changes []Change
+
+// Max returns the most disruptive kind of the changes, Additive if there are none
+func (c Changes) Max() ChangeKind {
+	max := Additive
+	for _, change := range c {
+		if change.Kind > max {
+			max = change.Kind
+		}
+	}
+	return max
+}
+
+// DiffDefinitions returns the changes between the old and the new definitions,
+// sorted by name and sub
+func DiffDefinitions(old, next Definitions) Changes {
+	var changes Changes
+	add := func(kind ChangeKind, name, sub, desc string) {
+		changes = append(changes, Change{Kind: kind, Name: name, Sub: sub, Description: desc})
+	}
+
+	for i := range old {
+		o := &old[i]
+		n := next.byName(o.Name)
+		if n == nil {
+			add(Breaking, o.Name, "", "definition removed")
+			continue
+		}
+
+		for _, sub := range missing(o.Subset, n.Subset) {
+			add(Breaking, o.Name, sub, "removed from the subset")
+		}
+		for _, sub := range missing(n.Subset, o.Subset) {
+			add(Additive, o.Name, sub, "added to the subset")
+		}
+		for _, sub := range missing(o.DefaultSubset, n.DefaultSubset) {
+			// removing a sub from the subset also removes it from the default subset
+			if InStringSlice(n.Subset, sub) {
+				add(Narrowing, o.Name, sub, "removed from the default subset")
+			}
+		}
+		for _, sub := range missing(n.DefaultSubset, o.DefaultSubset) {
+			add(Additive, o.Name, sub, "added to the default subset")
+		}
+	}
+
+	for i := range next {
+		if old.byName(next[i].Name) == nil {
+			add(Additive, next[i].Name, "", "definition added")
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Sub < changes[j].Sub
+	})
+	return changes
+}
+
+// byName returns the Definition with the given name
+func (d Definitions) byName(name string) *Definition {
+	for i := range d {
+		if d[i].Name == name {
+			return &d[i]
+		}
+	}
+	return nil
+}
+
+// missing returns the elements of a that are not in b
+func missing(a, b []string) []string {
+	var m []string
+	for _, s := range a {
+		if !InStringSlice(b, s) {
+			m = append(m, s)
+		}
+	}
+	return m
+}
+
+// Lost returns the sorted permissions that the scope satisfies with the old definitions
+// but not with the new ones.
+// Every name and sub of the old definitions is evaluated as a required permission
+func Lost(old, next Definitions, s Scope) Scope {
+	lost := Scope{}
+	for i := range old {
+		candidates := []Permission{{Name: old[i].Name}}
+		for _, sub := range old[i].Subset {
+			candidates = append(candidates, Permission{Name: old[i].Name, Sub: sub})
+		}
+
+		for _, p := range candidates {
+			required := Scope{p}
+			if old.decide(required, s).Allowed && !next.decide(required, s).Allowed {
+				lost = append(lost, p)
+			}
+		}
+	}
+
+	SortScope(lost)
+	return lost
+}
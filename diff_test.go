@@ -0,0 +1,74 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDefinitions(t *testing.T) {
+	old := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile", "about"}},
+		{Name: "playlist", Subset: []string{"edit", "read"}, DefaultSubset: []string{"read"}},
+		{Name: "legacy"},
+	}
+
+	new := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "about", "friends"}, DefaultSubset: []string{"profile", "friends"}},
+		{Name: "playlist", Subset: []string{"edit", "share"}},
+		{Name: "repo", Subset: []string{"read"}},
+	}
+
+	changes := DiffDefinitions(old, new)
+	assert.Equal(t, Changes{
+		{Kind: Breaking, Name: "legacy", Description: "definition removed"},
+		{Kind: Breaking, Name: "playlist", Sub: "read", Description: "removed from the subset"},
+		{Kind: Additive, Name: "playlist", Sub: "share", Description: "added to the subset"},
+		{Kind: Additive, Name: "repo", Description: "definition added"},
+		{Kind: Narrowing, Name: "user", Sub: "about", Description: "removed from the default subset"},
+		{Kind: Breaking, Name: "user", Sub: "email", Description: "removed from the subset"},
+		{Kind: Additive, Name: "user", Sub: "friends", Description: "added to the subset"},
+		{Kind: Additive, Name: "user", Sub: "friends", Description: "added to the default subset"},
+	}, changes)
+	assert.Equal(t, Breaking, changes.Max())
+	assert.Equal(t, "breaking: user.email: removed from the subset", changes[5].String())
+
+	assert.Len(t, DiffDefinitions(old, old), 0)
+	assert.Equal(t, Additive, DiffDefinitions(old, old).Max())
+	assert.Equal(t, Narrowing, DiffDefinitions(old[:1], Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile"}},
+	}).Max())
+
+	assert.Equal(t, "additive", Additive.String())
+	assert.Equal(t, "narrowing", Narrowing.String())
+
+	var k ChangeKind
+	assert.NoError(t, k.UnmarshalText([]byte("narrowing")))
+	assert.Equal(t, Narrowing, k)
+	assert.Equal(t, ErrBadFormat, k.UnmarshalText([]byte("major")))
+}
+
+func TestLost(t *testing.T) {
+	old := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile", "about"}},
+		{Name: "legacy"},
+	}
+
+	new := Definitions{
+		{Name: "user", Subset: []string{"edit", "profile", "about"}, DefaultSubset: []string{"profile"}},
+	}
+
+	s, _ := ParseScope("user,user.email,legacy")
+	assert.Equal(t, Scope{
+		{Name: "legacy"},
+		{Name: "user", Sub: "about"},
+		{Name: "user", Sub: "email"},
+	}, Lost(old, new, s))
+
+	// a required user was satisfied by user.about, which is no longer a default sub
+	s, _ = ParseScope("user.edit,user.about")
+	assert.Equal(t, Scope{{Name: "user"}}, Lost(old, new, s))
+
+	s, _ = ParseScope("user.edit")
+	assert.Equal(t, Scope{}, Lost(old, new, s))
+}
@@ -0,0 +1,159 @@
+// Package docs renders the documentation of definitions as Markdown or HTML.
+//
+// Every definition gets a table listing its permissions, their description
+// and wether they are part of the default subset, followed by the implied permissions:
+// the subs granted by a permission only specified by its name.
+package docs
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/asdine/permission"
+)
+
+// DefaultTitle is the title used when Options.Title is empty
+const DefaultTitle = "Permissions"
+
+// Options of the renderers
+type Options struct {
+	Title string
+}
+
+// Row documents a single permission
+type Row struct {
+	Permission  permission.Permission
+	Description string
+	Default     bool
+}
+
+// Section documents a definition
+type Section struct {
+	Definition permission.Definition
+	Rows       []Row
+}
+
+// Implication lists the permissions granted by a permission only specified by its name
+type Implication struct {
+	Permission permission.Permission
+	Implies    []permission.Permission
+}
+
+// Catalogue is the data rendered by Markdown and HTML
+type Catalogue struct {
+	Title        string
+	Sections     []Section
+	Implications []Implication
+}
+
+// NewCatalogue returns the catalogue of the definitions, in the order of the definitions
+func NewCatalogue(defs permission.Definitions, opts Options) *Catalogue {
+	c := Catalogue{Title: opts.Title}
+	if c.Title == "" {
+		c.Title = DefaultTitle
+	}
+
+	for _, def := range defs {
+		s := Section{Definition: def}
+		s.Rows = append(s.Rows, Row{Permission: permission.Permission{Name: def.Name}, Description: def.Description})
+		for _, sub := range def.Subset {
+			s.Rows = append(s.Rows, Row{
+				Permission:  permission.Permission{Name: def.Name, Sub: sub},
+				Description: def.SubDescriptions[sub],
+				Default:     permission.InStringSlice(def.DefaultSubset, sub),
+			})
+		}
+		c.Sections = append(c.Sections, s)
+
+		if len(def.DefaultSubset) > 0 {
+			i := Implication{Permission: permission.Permission{Name: def.Name}}
+			for _, sub := range def.DefaultSubset {
+				i.Implies = append(i.Implies, permission.Permission{Name: def.Name, Sub: sub})
+			}
+			c.Implications = append(c.Implications, i)
+		}
+	}
+
+	return &c
+}
+
+// Markdown writes the documentation of the definitions as Markdown
+func Markdown(w io.Writer, defs permission.Definitions, opts Options) error {
+	return markdownTmpl.Execute(w, NewCatalogue(defs, opts))
+}
+
+// HTML writes the documentation of the definitions as an HTML fragment
+func HTML(w io.Writer, defs permission.Definitions, opts Options) error {
+	return htmlTmpl.Execute(w, NewCatalogue(defs, opts))
+}
+
+// cell escapes the text for a Markdown table cell or paragraph:
+// the text is kept on one line and the characters starting Markdown syntax or HTML are escaped
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "#", `\#`, "[", `\[`, "]", `\]`, "`", "\\`",
+	"*", `\*`, "_", `\_`, "<", "&lt;", ">", "&gt;",
+)
+
+var markdownTmpl = texttemplate.Must(texttemplate.New("markdown").Funcs(texttemplate.FuncMap{
+	"cell": cell,
+}).Parse(`# {{ cell .Title }}
+{{ range .Sections }}
+## {{ cell .Definition.Name }}
+{{ with .Definition.Description }}
+{{ cell . }}
+{{ end }}
+| Permission | Default | Description |
+| --- | --- | --- |
+{{- range .Rows }}
+| ` + "`{{ .Permission }}`" + ` | {{ if .Default }}yes{{ end }} | {{ cell .Description }} |
+{{- end }}
+{{ end }}
+{{- with .Implications }}
+## Implied permissions
+
+| Permission | Implies |
+| --- | --- |
+{{- range . }}
+| ` + "`{{ .Permission }}`" + ` | {{ range $i, $p := .Implies }}{{ if $i }}, {{ end }}` + "`{{ $p }}`" + `{{ end }} |
+{{- end }}
+{{ end -}}
+`))
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Parse(`<h1>{{ .Title }}</h1>
+{{ range .Sections }}
+<h2 id="{{ .Definition.Name }}">{{ .Definition.Name }}</h2>
+{{- with .Definition.Description }}
+<p>{{ . }}</p>
+{{- end }}
+<table>
+  <thead>
+    <tr><th>Permission</th><th>Default</th><th>Description</th></tr>
+  </thead>
+  <tbody>
+{{- range .Rows }}
+    <tr><td><code>{{ .Permission }}</code></td><td>{{ if .Default }}yes{{ end }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+  </tbody>
+</table>
+{{ end }}
+{{- with .Implications }}
+<h2 id="implied-permissions">Implied permissions</h2>
+<table>
+  <thead>
+    <tr><th>Permission</th><th>Implies</th></tr>
+  </thead>
+  <tbody>
+{{- range . }}
+    <tr><td><code>{{ .Permission }}</code></td><td>{{ range $i, $p := .Implies }}{{ if $i }}, {{ end }}<code>{{ $p }}</code>{{ end }}</td></tr>
+{{- end }}
+  </tbody>
+</table>
+{{ end -}}
+`))
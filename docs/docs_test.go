@@ -0,0 +1,77 @@
+package docs
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testDefinitions(t *testing.T) permission.Definitions {
+	data, err := os.ReadFile("testdata/definitions.json")
+	require.NoError(t, err)
+
+	defs, err := permission.LoadDefinitions(data)
+	require.NoError(t, err)
+	return defs
+}
+
+func golden(t *testing.T, name string, actual []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, actual, 0644))
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Markdown(&buf, testDefinitions(t), Options{Title: "OAuth scopes"}))
+	golden(t, "catalogue.md", buf.Bytes())
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HTML(&buf, testDefinitions(t), Options{}))
+	golden(t, "catalogue.html", buf.Bytes())
+}
+
+func TestNewCatalogue(t *testing.T) {
+	c := NewCatalogue(testDefinitions(t), Options{})
+	assert.Equal(t, DefaultTitle, c.Title)
+	require.Len(t, c.Sections, 3)
+	assert.Len(t, c.Sections[0].Rows, 5)
+	assert.Equal(t, Row{Permission: permission.Permission{Name: "user", Sub: "profile"}, Description: "Read the public profile", Default: true}, c.Sections[0].Rows[2])
+	assert.Equal(t, []Implication{
+		{Permission: permission.Permission{Name: "user"}, Implies: []permission.Permission{{Name: "user", Sub: "profile"}, {Name: "user", Sub: "about"}}},
+		{Permission: permission.Permission{Name: "playlist"}, Implies: []permission.Permission{{Name: "playlist", Sub: "read"}}},
+	}, c.Implications)
+
+	c = NewCatalogue(permission.Definitions{{Name: "admin"}}, Options{})
+	assert.Len(t, c.Implications, 0)
+}
+
+func TestMarkdownEscaping(t *testing.T) {
+	defs := permission.Definitions{{
+		Name:            "user",
+		Description:     "# Title\n[click](http://evil) <script>x</script> a|b *bold* `code`",
+		Subset:          []string{"edit"},
+		SubDescriptions: map[string]string{"edit": "Edit | [link](x)"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Markdown(&buf, defs, Options{}))
+	assert.Contains(t, buf.String(), "\n\\# Title \\[click\\](http://evil) &lt;script&gt;x&lt;/script&gt; a\\|b \\*bold\\* \\`code\\`\n")
+	assert.Contains(t, buf.String(), "| Edit \\| \\[link\\](x) |")
+	assert.NotContains(t, buf.String(), "<script>")
+}
@@ -7,4 +7,17 @@ var (
 	ErrEmptyName  = errors.New("The permission name is empty")
 	ErrEmptyInput = errors.New("The given input is an empty string")
 	ErrBadFormat  = errors.New("The given input is not in the correct format")
+
+	ErrUnsupportedType = errors.New("The given value type is not supported")
+	ErrNullElement     = errors.New("The given array contains a NULL element")
+	ErrEmptySubject    = errors.New("The subject is empty")
+	ErrEmptyRole       = errors.New("The role name is empty")
+	ErrEmptyTenant     = errors.New("The tenant name is empty")
+	ErrEmptyGroup      = errors.New("The group name is empty")
+	ErrCycle           = errors.New("The membership would create a cycle")
+	ErrDuplicate       = errors.New("The name is declared twice")
+	ErrBadDefault      = errors.New("The default subset is not included in the subset")
+	ErrUnknownSub      = errors.New("The sub permission is not included in the subset")
+	ErrUndefined       = errors.New("The permission is not defined")
+	ErrMismatch        = errors.New("The scope was encoded with other definitions")
 )
@@ -0,0 +1,152 @@
+package permission
+
+import (
+	"testing"
+)
+
+var fuzzDefs = Definitions{
+	{Name: "user", Subset: []string{"edit", "profile", "email", "about"}, DefaultSubset: []string{"profile", "about"}},
+	{Name: "playlist", Subset: []string{"edit", "share", "read"}, DefaultSubset: []string{"read", "share"}},
+	{Name: "admin"},
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"a", "a.b", "user.edit", "a.", ".b", "a.b.c", "", "ünï.cödé"} {
+		f.Add(seed)
+	}
+	r := Compile(fuzzDefs)
+
+	f.Fuzz(func(t *testing.T, repr string) {
+		p, err := Parse(repr)
+		if err != nil {
+			if !p.IsZero() {
+				t.Fatalf("%q: %v returned with %v", repr, p, err)
+			}
+			return
+		}
+
+		if p.Name == "" {
+			t.Fatalf("%q: empty name", repr)
+		}
+		if p.String() != repr {
+			t.Fatalf("%q: String returned %q", repr, p.String())
+		}
+
+		q, err := Parse(p.String())
+		if err != nil || !q.Equal(p) {
+			t.Fatalf("%q: Parse(String()) returned %v, %v", repr, q, err)
+		}
+
+		text, err := p.MarshalText()
+		if err != nil || string(text) != repr {
+			t.Fatalf("%q: MarshalText returned %q, %v", repr, text, err)
+		}
+
+		i, err := r.Parse(repr)
+		if err != nil || !i.Equal(p) {
+			t.Fatalf("%q: Registry.Parse returned %v, %v", repr, i, err)
+		}
+	})
+}
+
+func FuzzParseScope(f *testing.F) {
+	for _, seed := range []string{"a", "a,b.c", "user,user.edit,playlist", "a,,b", "a,b.", ",", "a.b.c,d", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, repr string) {
+		s, err := ParseScope(repr)
+		if err != nil {
+			if s != nil {
+				t.Fatalf("%q: %v returned with %v", repr, s, err)
+			}
+			return
+		}
+
+		text, err := s.MarshalText()
+		if err != nil {
+			t.Fatalf("%q: MarshalText failed: %v", repr, err)
+		}
+		if string(text) != repr {
+			t.Fatalf("%q: MarshalText returned %q", repr, text)
+		}
+
+		u, err := ParseScope(string(text))
+		if err != nil || len(u) != len(s) {
+			t.Fatalf("%q: ParseScope(MarshalText()) returned %v, %v", repr, u, err)
+		}
+		for i := range s {
+			if !u[i].Equal(s[i]) {
+				t.Fatalf("%q: ParseScope(MarshalText()) returned %v", repr, u)
+			}
+		}
+
+		buf, err := s.AppendText(nil)
+		if err != nil || string(buf) != string(text) {
+			t.Fatalf("%q: AppendText returned %q, %v", repr, buf, err)
+		}
+
+		into, err := ParseScopeInto(make(Scope, 0, 1), repr)
+		if err != nil || len(into) != len(s) {
+			t.Fatalf("%q: ParseScopeInto returned %v, %v", repr, into, err)
+		}
+
+		n := s.Normalize()
+		if len(n.Union(s)) != len(n) || len(s.Difference(n)) != 0 {
+			t.Fatalf("%q: Normalize returned %v", repr, n)
+		}
+	})
+}
+
+func FuzzRequire(f *testing.F) {
+	for _, seed := range [][3]string{
+		{"user", "user", "playlist"},
+		{"user.edit", "user", "user.edit"},
+		{"user.profile,playlist", "playlist.read", "admin"},
+		{"admin", "nope", "admin"},
+		{"user.", "user", "user"},
+		{"playlist.edit", "playlist.share", "playlist"},
+	} {
+		f.Add(seed[0], seed[1], seed[2])
+	}
+	r := Compile(fuzzDefs)
+
+	f.Fuzz(func(t *testing.T, required, scope, extra string) {
+		req, err := ParseScope(required)
+		if err != nil {
+			return
+		}
+		s, err := ParseScope(scope)
+		if err != nil {
+			return
+		}
+		e, err := ParseScope(extra)
+		if err != nil {
+			return
+		}
+
+		allowed := fuzzDefs.RequireScope(req, s)
+		if fuzzDefs.Require(required, scope) != allowed {
+			t.Fatalf("Require(%q, %q) differs from RequireScope", required, scope)
+		}
+		if r.Require(req, s) != allowed {
+			t.Fatalf("Registry.Require(%q, %q) differs from RequireScope", required, scope)
+		}
+
+		// adding permissions never revokes access
+		more := append(append(Scope{}, s...), e...)
+		if allowed && !fuzzDefs.RequireScope(req, more) {
+			t.Fatalf("Require(%q, %q) is allowed but not with %q", required, scope, extra)
+		}
+
+		// neither does requiring more alternatives
+		if allowed && !fuzzDefs.RequireScope(append(append(Scope{}, req...), e...), s) {
+			t.Fatalf("Require(%q, %q) is allowed but not with the alternatives %q", required, scope, extra)
+		}
+
+		// the order of the permissions doesn't matter
+		if fuzzDefs.RequireScope(req.Normalize(), s.Normalize()) != allowed {
+			t.Fatalf("Require(%q, %q) depends on the order of the permissions", required, scope)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Clock tells the current time.
+// It allows to control time when evaluating Grants
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clock
+type ClockFunc func() time.Time
+
+// Now calls f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock returning the system time
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Grant is a Permission only valid during a time window and,
+// optionally, only for requests satisfying a Condition.
+// It is useful to give temporary or restricted access
+type Grant struct {
+	Permission Permission
+
+	// NotBefore is the time from which the grant is valid.
+	// The zero value means the grant is valid from the beginning of time
+	NotBefore time.Time
+
+	// ExpiresAt is the time from which the grant is no longer valid.
+	// The zero value means the grant never expires
+	ExpiresAt time.Time
+
+	// Condition the request attributes must satisfy.
+	// A nil Condition is always satisfied
+	Condition *Condition
+}
+
+// ValidAt reports whether the grant is valid at the given time
+func (g Grant) ValidAt(t time.Time) bool {
+	if !g.NotBefore.IsZero() && t.Before(g.NotBefore) {
+		return false
+	}
+
+	if !g.ExpiresAt.IsZero() && !t.Before(g.ExpiresAt) {
+		return false
+	}
+
+	return true
+}
+
+type jsonGrant struct {
+	Permission Permission `json:"permission"`
+	NotBefore  *time.Time `json:"notBefore,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	Condition  *Condition `json:"condition,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Zero timestamps are omitted
+func (g Grant) MarshalJSON() ([]byte, error) {
+	j := jsonGrant{Permission: g.Permission, Condition: g.Condition}
+	if !g.NotBefore.IsZero() {
+		j.NotBefore = &g.NotBefore
+	}
+	if !g.ExpiresAt.IsZero() {
+		j.ExpiresAt = &g.ExpiresAt
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (g *Grant) UnmarshalJSON(data []byte) error {
+	var j jsonGrant
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	if j.Permission.Name == "" {
+		return ErrEmptyName
+	}
+
+	*g = Grant{Permission: j.Permission, Condition: j.Condition}
+	if j.NotBefore != nil {
+		g.NotBefore = *j.NotBefore
+	}
+	if j.ExpiresAt != nil {
+		g.ExpiresAt = *j.ExpiresAt
+	}
+
+	return nil
+}
+
+// Grants are a group of Grant
+type Grants []Grant
+
+// Active returns the grants valid at the time given by the clock
+func (g Grants) Active(clock Clock) Grants {
+	now := clock.Now()
+
+	var active Grants
+	for _, grant := range g {
+		if grant.ValidAt(now) {
+			active = append(active, grant)
+		}
+	}
+	return active
+}
+
+// Satisfied returns the grants whose condition is satisfied by the attributes.
+// A condition that cannot be evaluated is not satisfied
+func (g Grants) Satisfied(attrs Attributes) Grants {
+	var satisfied Grants
+	for _, grant := range g {
+		if grant.Condition != nil {
+			ok, err := grant.Condition.Eval(attrs)
+			if err != nil || !ok {
+				continue
+			}
+		}
+		satisfied = append(satisfied, grant)
+	}
+	return satisfied
+}
+
+// Check type checks the conditions of the grants against the schema
+func (g Grants) Check(schema Schema) error {
+	for _, grant := range g {
+		if grant.Condition == nil {
+			continue
+		}
+
+		err := grant.Condition.Check(schema)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadGrants decodes a JSON list of grants and type checks their conditions against the schema
+func LoadGrants(data []byte, schema Schema) (Grants, error) {
+	var g Grants
+	err := json.Unmarshal(data, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.Check(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Scope returns the permissions of the grants, regardless of their time window and condition
+func (g Grants) Scope() Scope {
+	s := make(Scope, 0, len(g))
+	for _, grant := range g {
+		if !s.HasPermission(grant.Permission) {
+			s = append(s, grant.Permission)
+		}
+	}
+	return s
+}
+
+// RequireGrants checks wether the grants active at the time given by the clock
+// match the required permission and are listed in the definitions.
+// Grants with a condition are ignored unless it is satisfied without attributes.
+// Returns false if the parsing fails
+func (d Definitions) RequireGrants(required string, g Grants, clock Clock) bool {
+	return d.RequireGrantsWith(required, g, clock, nil)
+}
+
+// RequireGrantsWith checks wether the grants active at the time given by the clock
+// and satisfied by the attributes match the required permission and are listed in the definitions.
+// Returns false if the parsing fails
+func (d Definitions) RequireGrantsWith(required string, g Grants, clock Clock, attrs Attributes) bool {
+	req, err := ParseScope(required)
+	if err != nil {
+		fail(context.Background(), "", "", err)
+		return false
+	}
+
+	return d.RequireScope(req, g.Active(clock).Satisfied(attrs).Scope())
+}
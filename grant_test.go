@@ -0,0 +1,209 @@
+package permission
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestGrantValidAt(t *testing.T) {
+	start := time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	g := Grant{Permission: Permission{Name: "admin"}}
+	assert.True(t, g.ValidAt(start))
+
+	g = Grant{Permission: Permission{Name: "admin"}, NotBefore: start, ExpiresAt: end}
+	assert.False(t, g.ValidAt(start.Add(-time.Nanosecond)))
+	assert.True(t, g.ValidAt(start))
+	assert.True(t, g.ValidAt(end.Add(-time.Nanosecond)))
+	assert.False(t, g.ValidAt(end))
+
+	g = Grant{Permission: Permission{Name: "admin"}, ExpiresAt: end}
+	assert.True(t, g.ValidAt(time.Time{}))
+	assert.False(t, g.ValidAt(end))
+
+	g = Grant{Permission: Permission{Name: "admin"}, NotBefore: start}
+	assert.False(t, g.ValidAt(time.Time{}))
+	assert.True(t, g.ValidAt(end.Add(1000*time.Hour)))
+}
+
+func TestGrantsActive(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	g := Grants{
+		{Permission: Permission{Name: "user", Sub: "profile"}},
+		{Permission: Permission{Name: "admin"}, ExpiresAt: clock.now.Add(2 * time.Hour)},
+		{Permission: Permission{Name: "billing"}, NotBefore: clock.now.Add(time.Hour)},
+	}
+
+	assert.Equal(t, Scope{{Name: "user", Sub: "profile"}, {Name: "admin"}}, g.Active(clock).Scope())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, Scope{{Name: "user", Sub: "profile"}, {Name: "admin"}, {Name: "billing"}}, g.Active(clock).Scope())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, Scope{{Name: "user", Sub: "profile"}, {Name: "billing"}}, g.Active(clock).Scope())
+
+	assert.Len(t, Grants(nil).Active(clock), 0)
+}
+
+func TestGrantsScope(t *testing.T) {
+	g := Grants{
+		{Permission: Permission{Name: "admin"}},
+		{Permission: Permission{Name: "admin"}, ExpiresAt: time.Now()},
+		{Permission: Permission{Name: "user", Sub: "edit"}},
+	}
+
+	assert.Equal(t, Scope{{Name: "admin"}, {Name: "user", Sub: "edit"}}, g.Scope())
+}
+
+func TestGrantJSON(t *testing.T) {
+	start := time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	g := Grant{Permission: Permission{Name: "user", Sub: "edit"}}
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	assert.Equal(t, `{"permission":"user.edit"}`, string(data))
+
+	g = Grant{Permission: Permission{Name: "admin"}, NotBefore: start, ExpiresAt: start.Add(2 * time.Hour)}
+	data, err = json.Marshal(g)
+	require.NoError(t, err)
+	assert.Equal(t, `{"permission":"admin","notBefore":"2016-01-01T10:00:00Z","expiresAt":"2016-01-01T12:00:00Z"}`, string(data))
+
+	var h Grant
+	err = json.Unmarshal(data, &h)
+	require.NoError(t, err)
+	assert.True(t, g.NotBefore.Equal(h.NotBefore))
+	assert.True(t, g.ExpiresAt.Equal(h.ExpiresAt))
+	assert.Equal(t, g.Permission, h.Permission)
+
+	err = json.Unmarshal([]byte(`{"permission":"user"}`), &h)
+	require.NoError(t, err)
+	assert.Equal(t, Grant{Permission: Permission{Name: "user"}}, h)
+
+	err = json.Unmarshal([]byte(`{"expiresAt":"2016-01-01T12:00:00Z"}`), &h)
+	assert.Equal(t, ErrEmptyName, err)
+
+	err = json.Unmarshal([]byte(`{"permission":"a.b.c"}`), &h)
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(`{"permission":"a","notBefore":"yesterday"}`), &h)
+	assert.Error(t, err)
+}
+
+func TestDefinitions_RequireGrants(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	d := Definitions{
+		{Name: "admin"},
+		{
+			Name:          "user",
+			Subset:        []string{"profile", "edit"},
+			DefaultSubset: []string{"profile"},
+		},
+	}
+
+	g := Grants{
+		{Permission: Permission{Name: "user"}},
+		{Permission: Permission{Name: "admin"}, NotBefore: clock.now.Add(time.Minute), ExpiresAt: clock.now.Add(2 * time.Hour)},
+	}
+
+	assert.True(t, d.RequireGrants("user.profile", g, clock))
+	assert.False(t, d.RequireGrants("user.edit", g, clock))
+	assert.False(t, d.RequireGrants("admin", g, clock))
+
+	clock.Advance(time.Minute)
+	assert.True(t, d.RequireGrants("admin", g, clock))
+
+	clock.Advance(2 * time.Hour)
+	assert.False(t, d.RequireGrants("admin", g, clock))
+	assert.True(t, d.RequireGrants("admin,user", g, clock))
+	assert.False(t, d.RequireGrants("admin,", g, clock))
+}
+
+func TestGrantsSatisfied(t *testing.T) {
+	cond, err := ParseCondition("amount < 10000")
+	require.NoError(t, err)
+
+	g := Grants{
+		{Permission: Permission{Name: "invoice", Sub: "read"}},
+		{Permission: Permission{Name: "invoice", Sub: "approve"}, Condition: cond},
+	}
+
+	assert.Equal(t, g, g.Satisfied(Attributes{"amount": 500}))
+	assert.Equal(t, g[:1], g.Satisfied(Attributes{"amount": 50000}))
+	assert.Equal(t, g[:1], g.Satisfied(Attributes{"amount": "500"}))
+	assert.Equal(t, g[:1], g.Satisfied(nil))
+}
+
+func TestLoadGrants(t *testing.T) {
+	schema := Schema{"amount": NumberAttribute}
+
+	g, err := LoadGrants([]byte(`[
+		{"permission": "invoice.read"},
+		{"permission": "invoice.approve", "condition": "amount < 10000", "expiresAt": "2016-01-01T12:00:00Z"}
+	]`), schema)
+	require.NoError(t, err)
+	require.Len(t, g, 2)
+	assert.Nil(t, g[0].Condition)
+	assert.Equal(t, "amount < 10000", g[1].Condition.String())
+
+	data, err := json.Marshal(g[1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"permission":"invoice.approve","expiresAt":"2016-01-01T12:00:00Z","condition":"amount \u003c 10000"}`, string(data))
+
+	_, err = LoadGrants([]byte(`[{"permission": "invoice.approve", "condition": "amount < '10000'"}]`), schema)
+	assert.IsType(t, &ConditionError{}, err)
+
+	_, err = LoadGrants([]byte(`[{"permission": "invoice.approve", "condition": "total < 10000"}]`), schema)
+	assert.IsType(t, &ConditionError{}, err)
+
+	_, err = LoadGrants([]byte(`[{"permission": "invoice.approve", "condition": "amount <"}]`), schema)
+	assert.Error(t, err)
+
+	_, err = LoadGrants([]byte(`{}`), schema)
+	assert.Error(t, err)
+}
+
+func TestDefinitions_RequireGrantsWith(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	d := Definitions{
+		{Name: "invoice", Subset: []string{"read", "approve"}},
+		{Name: "repo", Subset: []string{"read", "write"}},
+	}
+
+	g, err := LoadGrants([]byte(`[
+		{"permission": "invoice.approve", "condition": "amount < 10000"},
+		{"permission": "repo.write", "condition": "repo.org == caller.org", "expiresAt": "2016-01-01T11:00:00Z"}
+	]`), Schema{"amount": NumberAttribute, "repo.org": StringAttribute, "caller.org": StringAttribute})
+	require.NoError(t, err)
+
+	assert.True(t, d.RequireGrantsWith("invoice.approve", g, clock, Attributes{"amount": 9999.5}))
+	assert.False(t, d.RequireGrantsWith("invoice.approve", g, clock, Attributes{"amount": 10000}))
+	assert.False(t, d.RequireGrantsWith("invoice.approve", g, clock, nil))
+	assert.False(t, d.RequireGrants("invoice.approve", g, clock))
+
+	attrs := Attributes{"repo.org": "acme", "caller.org": "acme"}
+	assert.True(t, d.RequireGrantsWith("repo.write", g, clock, attrs))
+	assert.False(t, d.RequireGrantsWith("repo.write", g, clock, Attributes{"repo.org": "acme", "caller.org": "other"}))
+
+	clock.Advance(time.Hour)
+	assert.False(t, d.RequireGrantsWith("repo.write", g, clock, attrs))
+}
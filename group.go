@@ -0,0 +1,258 @@
+package permission
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Groups manages groups of subjects, like teams, and the scopes granted to them.
+// A group can be a member of other groups, the members of a group
+// receive the scopes of all the groups it belongs to, directly or transitively.
+// Resolved scopes are cached until the memberships or grants they depend on change.
+// It is safe for concurrent use
+type Groups struct {
+	mu sync.RWMutex
+
+	// parents lists the groups each member directly belongs to
+	parents map[string]map[string]struct{}
+	// children lists the direct members of each group
+	children map[string]map[string]struct{}
+	grants   map[string]Scope
+	cache    map[string]Scope
+}
+
+// NewGroups returns an empty Groups
+func NewGroups() *Groups {
+	return &Groups{
+		parents:  make(map[string]map[string]struct{}),
+		children: make(map[string]map[string]struct{}),
+		grants:   make(map[string]Scope),
+		cache:    make(map[string]Scope),
+	}
+}
+
+// AddMember adds the member to the group.
+// The member can be a subject or another group.
+// Returns ErrCycle if the group is the member or already belongs to it
+func (g *Groups) AddMember(group, member string) error {
+	err := validateMembership(group, member)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if group == member || g.belongsTo(group, member) {
+		return ErrCycle
+	}
+
+	link(g.parents, member, group)
+	link(g.children, group, member)
+	g.invalidate(member)
+	return nil
+}
+
+// RemoveMember removes the member from the group
+func (g *Groups) RemoveMember(group, member string) error {
+	err := validateMembership(group, member)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	unlink(g.parents, member, group)
+	unlink(g.children, group, member)
+	g.invalidate(member)
+	return nil
+}
+
+// Grant adds the permissions of the scope to the group grants
+func (g *Groups) Grant(group string, s Scope) error {
+	err := ValidateGrant(group, s)
+	if err == ErrEmptySubject {
+		return ErrEmptyGroup
+	}
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.grants[group] = union(g.grants[group], s)
+	g.invalidate(group)
+	return nil
+}
+
+// Revoke removes the permissions of the scope from the group grants
+func (g *Groups) Revoke(group string, s Scope) error {
+	err := ValidateGrant(group, s)
+	if err == ErrEmptySubject {
+		return ErrEmptyGroup
+	}
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var kept Scope
+	for _, p := range g.grants[group] {
+		if !s.HasPermission(p) {
+			kept = append(kept, p)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(g.grants, group)
+	} else {
+		g.grants[group] = kept
+	}
+	g.invalidate(group)
+	return nil
+}
+
+// GroupsOf returns the sorted groups the member belongs to, directly or transitively
+func (g *Groups) GroupsOf(member string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var groups []string
+	g.walk(member, func(group string) {
+		groups = append(groups, group)
+	})
+
+	sort.Strings(groups)
+	return groups
+}
+
+// ScopeOf returns the sorted permissions granted to the groups the member belongs to.
+// Only the scopes of members are cached, so that querying unknown subjects doesn't grow the cache
+func (g *Groups) ScopeOf(member string) Scope {
+	g.mu.RLock()
+	s, ok := g.cache[member]
+	_, isMember := g.parents[member]
+	g.mu.RUnlock()
+	if ok {
+		return append(Scope{}, s...)
+	}
+
+	if !isMember {
+		return Scope{}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if s, ok = g.cache[member]; ok {
+		return append(Scope{}, s...)
+	}
+
+	if _, isMember = g.parents[member]; !isMember {
+		return Scope{}
+	}
+
+	s = Scope{}
+	g.walk(member, func(group string) {
+		s = union(s, g.grants[group])
+	})
+	SortScope(s)
+
+	g.cache[member] = s
+	return append(Scope{}, s...)
+}
+
+// Resolve returns the union of the direct scope of the subject and of the scopes of its groups
+func (g *Groups) Resolve(subject string, direct Scope) Scope {
+	return union(union(nil, direct), g.ScopeOf(subject))
+}
+
+// RequireGroups checks wether the direct scope of the subject and the scopes of its groups
+// match the required permission and are listed in the definitions.
+// Returns false if the parsing fails
+func (d Definitions) RequireGroups(required string, g *Groups, subject string, direct Scope) bool {
+	req, err := ParseScope(required)
+	if err != nil {
+		fail(context.Background(), "", subject, err)
+		return false
+	}
+
+	return d.Decide(context.Background(), subject, req, g.Resolve(subject, direct)).Allowed
+}
+
+// belongsTo reports whether member belongs to group, directly or transitively
+func (g *Groups) belongsTo(member, group string) bool {
+	found := false
+	g.walk(member, func(parent string) {
+		found = found || parent == group
+	})
+	return found
+}
+
+// walk calls fn once for every group the member belongs to
+func (g *Groups) walk(member string, fn func(group string)) {
+	seen := make(map[string]bool)
+	stack := []string{member}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for parent := range g.parents[current] {
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			fn(parent)
+			stack = append(stack, parent)
+		}
+	}
+}
+
+// invalidate removes from the cache the member and everything that belongs to it
+func (g *Groups) invalidate(member string) {
+	stack := []string{member}
+	seen := map[string]bool{member: true}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		delete(g.cache, current)
+
+		for child := range g.children[current] {
+			if !seen[child] {
+				seen[child] = true
+				stack = append(stack, child)
+			}
+		}
+	}
+}
+
+func validateMembership(group, member string) error {
+	if group == "" {
+		return ErrEmptyGroup
+	}
+
+	if member == "" {
+		return ErrEmptySubject
+	}
+
+	return nil
+}
+
+func link(m map[string]map[string]struct{}, from, to string) {
+	if m[from] == nil {
+		m[from] = make(map[string]struct{})
+	}
+	m[from][to] = struct{}{}
+}
+
+func unlink(m map[string]map[string]struct{}, from, to string) {
+	delete(m[from], to)
+	if len(m[from]) == 0 {
+		delete(m, from)
+	}
+}
@@ -0,0 +1,137 @@
+package permission
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupsMembership(t *testing.T) {
+	g := NewGroups()
+
+	require.NoError(t, g.AddMember("backend", "alice"))
+	require.NoError(t, g.AddMember("engineering", "backend"))
+	require.NoError(t, g.AddMember("staff", "engineering"))
+	require.NoError(t, g.AddMember("oncall", "alice"))
+
+	assert.Equal(t, []string{"backend", "engineering", "oncall", "staff"}, g.GroupsOf("alice"))
+	assert.Equal(t, []string{"engineering", "staff"}, g.GroupsOf("backend"))
+	assert.Len(t, g.GroupsOf("bob"), 0)
+
+	require.NoError(t, g.RemoveMember("engineering", "backend"))
+	require.NoError(t, g.RemoveMember("engineering", "nobody"))
+	assert.Equal(t, []string{"backend", "oncall"}, g.GroupsOf("alice"))
+
+	assert.Equal(t, ErrEmptyGroup, g.AddMember("", "alice"))
+	assert.Equal(t, ErrEmptySubject, g.AddMember("backend", ""))
+	assert.Equal(t, ErrEmptyGroup, g.RemoveMember("", "alice"))
+}
+
+func TestGroupsCycle(t *testing.T) {
+	g := NewGroups()
+
+	require.NoError(t, g.AddMember("b", "a"))
+	require.NoError(t, g.AddMember("c", "b"))
+
+	assert.Equal(t, ErrCycle, g.AddMember("a", "a"))
+	assert.Equal(t, ErrCycle, g.AddMember("a", "b"))
+	assert.Equal(t, ErrCycle, g.AddMember("a", "c"))
+	assert.Equal(t, ErrCycle, g.AddMember("b", "c"))
+
+	// diamonds are not cycles
+	require.NoError(t, g.AddMember("c", "a"))
+	assert.Equal(t, []string{"b", "c"}, g.GroupsOf("a"))
+}
+
+func TestGroupsScopeOf(t *testing.T) {
+	g := NewGroups()
+
+	require.NoError(t, g.Grant("backend", Scope{{Name: "repo", Sub: "write"}}))
+	require.NoError(t, g.Grant("engineering", Scope{{Name: "repo", Sub: "read"}, {Name: "repo", Sub: "write"}}))
+	require.NoError(t, g.AddMember("backend", "alice"))
+	require.NoError(t, g.AddMember("engineering", "backend"))
+
+	assert.Equal(t, Scope{{Name: "repo", Sub: "read"}, {Name: "repo", Sub: "write"}}, g.ScopeOf("alice"))
+	assert.Equal(t, Scope{{Name: "repo", Sub: "read"}, {Name: "repo", Sub: "write"}}, g.ScopeOf("backend"))
+	assert.Len(t, g.ScopeOf("engineering"), 0)
+	assert.Len(t, g.ScopeOf("bob"), 0)
+
+	// the cache is invalidated when a grant changes
+	require.NoError(t, g.Revoke("engineering", Scope{{Name: "repo", Sub: "read"}}))
+	assert.Equal(t, Scope{{Name: "repo", Sub: "write"}}, g.ScopeOf("alice"))
+
+	require.NoError(t, g.Grant("engineering", Scope{{Name: "wiki"}}))
+	assert.Equal(t, Scope{{Name: "repo", Sub: "write"}, {Name: "wiki"}}, g.ScopeOf("alice"))
+
+	// the cache is invalidated when a membership changes
+	require.NoError(t, g.RemoveMember("engineering", "backend"))
+	assert.Equal(t, Scope{{Name: "repo", Sub: "write"}}, g.ScopeOf("alice"))
+
+	require.NoError(t, g.AddMember("engineering", "alice"))
+	assert.Equal(t, Scope{{Name: "repo", Sub: "write"}, {Name: "wiki"}}, g.ScopeOf("alice"))
+
+	// returned scopes don't share the cache
+	s := g.ScopeOf("alice")
+	s[0] = Permission{Name: "admin"}
+	assert.Equal(t, Scope{{Name: "repo", Sub: "write"}, {Name: "wiki"}}, g.ScopeOf("alice"))
+
+	// subjects that don't belong to any group are not cached
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, Scope{}, g.ScopeOf(fmt.Sprintf("guest%d", i)))
+	}
+	assert.Len(t, g.cache, 1)
+	assert.NotContains(t, g.cache, "guest0")
+
+	assert.Equal(t, ErrEmptyGroup, g.Grant("", Scope{{Name: "wiki"}}))
+	assert.Equal(t, ErrEmptyName, g.Grant("backend", Scope{{}}))
+	assert.Equal(t, ErrEmptyGroup, g.Revoke("", Scope{{Name: "wiki"}}))
+}
+
+func TestGroupsConcurrency(t *testing.T) {
+	g := NewGroups()
+	require.NoError(t, g.Grant("team", Scope{{Name: "wiki"}}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			g.AddMember("team", "alice")
+			g.RemoveMember("team", "alice")
+		}()
+		go func() {
+			defer wg.Done()
+			g.ScopeOf("alice")
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, g.ScopeOf("alice"), 0)
+}
+
+func TestDefinitions_RequireGroups(t *testing.T) {
+	d := Definitions{
+		{
+			Name:          "repo",
+			Subset:        []string{"read", "write"},
+			DefaultSubset: []string{"read"},
+		},
+		{Name: "wiki"},
+	}
+
+	g := NewGroups()
+	require.NoError(t, g.Grant("engineering", Scope{{Name: "repo", Sub: "write"}}))
+	require.NoError(t, g.AddMember("backend", "alice"))
+	require.NoError(t, g.AddMember("engineering", "backend"))
+
+	direct := Scope{{Name: "wiki"}}
+
+	assert.True(t, d.RequireGroups("repo.write", g, "alice", direct))
+	assert.True(t, d.RequireGroups("wiki", g, "alice", direct))
+	assert.False(t, d.RequireGroups("repo.read", g, "alice", direct))
+	assert.False(t, d.RequireGroups("repo.write", g, "bob", direct))
+	assert.False(t, d.RequireGroups("repo.", g, "alice", direct))
+}
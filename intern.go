@@ -0,0 +1,51 @@
+package permission
+
+import "strings"
+
+// Intern returns the permission with the strings of the definitions of the registry,
+// so that equal permissions share their memory and Equal doesn't read them.
+// Undefined permissions are returned as is
+func (r *Registry) Intern(p Permission) Permission {
+	if i, ok := r.index[p]; ok {
+		return r.perms[i]
+	}
+	return p
+}
+
+// InternScope interns every permission of the scope, in place
+func (r *Registry) InternScope(s Scope) {
+	for i := range s {
+		s[i] = r.Intern(s[i])
+	}
+}
+
+// Parse parses repr and interns the permission.
+// An undefined permission is copied so that it doesn't retain the memory of repr
+func (r *Registry) Parse(repr string) (Permission, error) {
+	p, err := Parse(repr)
+	if err != nil {
+		return p, err
+	}
+	return r.intern(p), nil
+}
+
+// ParseScope parses repr and interns the permissions of the scope.
+// Undefined permissions are copied so that they don't retain the memory of repr
+func (r *Registry) ParseScope(repr string) (Scope, error) {
+	s, err := ParseScope(repr)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range s {
+		s[i] = r.intern(s[i])
+	}
+	return s, nil
+}
+
+func (r *Registry) intern(p Permission) Permission {
+	if i, ok := r.index[p]; ok {
+		return r.perms[i]
+	}
+	return Permission{Name: strings.Clone(p.Name), Sub: strings.Clone(p.Sub)}
+}
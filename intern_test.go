@@ -0,0 +1,133 @@
+package permission
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryIntern(t *testing.T) {
+	r := Compile(compileDefs)
+
+	p := Permission{Name: strings.Repeat("u", 1) + "ser", Sub: "pro" + strings.Repeat("file", 1)}
+	i := r.Intern(p)
+	assert.Equal(t, p, i)
+	assert.Same(t, unsafe.StringData(compileDefs[0].Name), unsafe.StringData(i.Name))
+	assert.Same(t, unsafe.StringData(compileDefs[0].Subset[1]), unsafe.StringData(i.Sub))
+
+	undefined := Permission{Name: "nope"}
+	assert.Same(t, unsafe.StringData(undefined.Name), unsafe.StringData(r.Intern(undefined).Name))
+
+	s := Scope{{Name: "playlist"}, {Name: "nope"}}
+	r.InternScope(s)
+	assert.Same(t, unsafe.StringData(compileDefs[1].Name), unsafe.StringData(s[0].Name))
+}
+
+func TestRegistryParse(t *testing.T) {
+	r := Compile(compileDefs)
+
+	p, err := r.Parse("user.edit")
+	require.NoError(t, err)
+	assert.Equal(t, Permission{Name: "user", Sub: "edit"}, p)
+	assert.Same(t, unsafe.StringData(compileDefs[0].Subset[0]), unsafe.StringData(p.Sub))
+
+	repr := "other.sub"
+	p, err = r.Parse(repr)
+	require.NoError(t, err)
+	assert.Equal(t, Permission{Name: "other", Sub: "sub"}, p)
+	assert.NotSame(t, unsafe.StringData(repr), unsafe.StringData(p.Name))
+
+	_, err = r.Parse("user.")
+	assert.Equal(t, ErrBadFormat, err)
+
+	s, err := r.ParseScope("playlist.read,admin,other")
+	require.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "playlist", Sub: "read"}, {Name: "admin"}, {Name: "other"}}, s)
+	assert.Same(t, unsafe.StringData(compileDefs[2].Name), unsafe.StringData(s[1].Name))
+
+	_, err = r.ParseScope("")
+	assert.Equal(t, ErrEmptyInput, err)
+}
+
+func TestEqualInterned(t *testing.T) {
+	r := Compile(compileDefs)
+	p, _ := r.Parse("user.profile")
+	q, _ := r.Parse("user.profile")
+	assert.Same(t, unsafe.StringData(p.Name), unsafe.StringData(q.Name))
+	assert.Same(t, unsafe.StringData(p.Sub), unsafe.StringData(q.Sub))
+	assert.True(t, p.Equal(q))
+	assert.True(t, p.Equal(Permission{Name: "user", Sub: "profile"}))
+	assert.False(t, p.Equal(Permission{Name: "user", Sub: "profiles"}))
+	assert.False(t, p.Equal(Permission{Name: "user"}))
+	assert.True(t, Permission{}.Equal(Permission{}))
+}
+
+// retained returns the heap memory used by the scopes parsed by fn, per scope
+func retained(b *testing.B, n int, fn func(repr string) Scope) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		scopes := make([]Scope, n)
+		for j := range scopes {
+			// every session reads its scope from the network
+			scopes[j] = fn(fmt.Sprintf("user.profile,user.edit,playlist.read,admin,session%d", j%10))
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(n), "B/scope")
+		runtime.KeepAlive(scopes)
+	}
+}
+
+func BenchmarkRetainedParseScope(b *testing.B) {
+	retained(b, 10000, func(repr string) Scope {
+		s, _ := ParseScope(repr)
+		return s
+	})
+}
+
+func BenchmarkRetainedRegistryParseScope(b *testing.B) {
+	r := Compile(compileDefs)
+	retained(b, 10000, func(repr string) Scope {
+		s, _ := r.ParseScope(repr)
+		return s
+	})
+}
+
+// boolSink stores the results of the benchmarks so that the compiler can't discard the calls
+var boolSink bool
+
+func BenchmarkEqual(b *testing.B) {
+	name, sub := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	r := Compile(Definitions{{Name: name, Subset: []string{sub}}})
+
+	p := Permission{Name: strings.Repeat("a", 64), Sub: strings.Repeat("b", 64)}
+	q := Permission{Name: strings.Repeat("a", 64), Sub: strings.Repeat("b", 64)}
+
+	b.Run("copies", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			boolSink = p.Equal(q)
+		}
+	})
+
+	// two permissions parsed from distinct inputs share the strings of the definitions
+	ip, err := r.Parse(name + "." + sub)
+	require.NoError(b, err)
+	iq, err := r.Parse(name + "." + sub)
+	require.NoError(b, err)
+
+	b.Run("interned", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			boolSink = ip.Equal(iq)
+		}
+	})
+}
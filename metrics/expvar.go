@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Expvar is a Sink publishing the metrics with expvar.
+// Decisions maps name.result to the number of decisions,
+// Latency maps each name to the count and the sum in seconds of the durations
+type Expvar struct {
+	Decisions *expvar.Map
+	Latency   *expvar.Map
+
+	mu sync.Mutex
+}
+
+// NewExpvar publishes the maps as prefix_decisions and prefix_latency_seconds.
+// It panics if the names are already published
+func NewExpvar(prefix string) *Expvar {
+	return &Expvar{
+		Decisions: expvar.NewMap(prefix + "_decisions"),
+		Latency:   expvar.NewMap(prefix + "_latency_seconds"),
+	}
+}
+
+// Count increments the number of decisions of the name with the result
+func (e *Expvar) Count(name, result string) {
+	e.Decisions.Add(name+"."+result, 1)
+}
+
+// Observe adds the duration to the latency of the name
+func (e *Expvar) Observe(name string, d time.Duration) {
+	e.mu.Lock()
+	m, ok := e.Latency.Get(name).(*expvar.Map)
+	if !ok {
+		m = new(expvar.Map).Init()
+		e.Latency.Set(name, m)
+	}
+	e.mu.Unlock()
+
+	m.Add("count", 1)
+	m.AddFloat("sum", d.Seconds())
+}
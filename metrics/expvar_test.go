@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpvar(t *testing.T) {
+	e := NewExpvar("test")
+	assert.Equal(t, e.Decisions, expvar.Get("test_decisions"))
+
+	e.Count("user", Allow)
+	e.Count("user", Allow)
+	e.Count("other", Deny)
+	e.Observe("user", time.Second)
+	e.Observe("user", time.Second/2)
+
+	assert.Equal(t, `{"other.deny": 1, "user.allow": 2}`, e.Decisions.String())
+	assert.Equal(t, `{"user": {"count": 2, "sum": 1.5}}`, e.Latency.String())
+
+	assert.Panics(t, func() { NewExpvar("test") })
+}
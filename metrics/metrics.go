@@ -0,0 +1,165 @@
+// Package metrics counts the decisions per permission and measures their latency.
+//
+// The metrics are recorded by a Collector, either registered as a decision hook
+// with permission.OnDecision to observe every evaluation, or used through
+// the Definitions wrapper to observe only its evaluations.
+// Labels are bounded to the names of the definitions, other names are reported as Other.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/asdine/permission"
+)
+
+// Results
+const (
+	Allow = "allow"
+	Deny  = "deny"
+	Error = "error"
+)
+
+// Other is the permission label of decisions on permissions that are not defined
+const Other = "other"
+
+// Sink receives the metrics
+type Sink interface {
+	// Count increments the number of decisions for the permission name with the result
+	Count(name, result string)
+
+	// Observe records the latency of a decision for the permission name
+	Observe(name string, d time.Duration)
+}
+
+// Collector records the metrics of decisions in a Sink
+type Collector struct {
+	names map[string]bool
+	sink  Sink
+}
+
+// NewCollector returns a Collector that labels the metrics with the names of the definitions
+func NewCollector(defs permission.Definitions, sink Sink) *Collector {
+	names := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		names[def.Name] = true
+	}
+
+	return &Collector{names: names, sink: sink}
+}
+
+// HandleDecision records the decision
+func (c *Collector) HandleDecision(ctx context.Context, d permission.Decision) {
+	name := c.label(d)
+
+	switch {
+	case d.Reason == permission.ReasonError:
+		c.sink.Count(name, Error)
+	case d.Allowed:
+		c.sink.Count(name, Allow)
+	default:
+		c.sink.Count(name, Deny)
+	}
+
+	c.sink.Observe(name, d.Duration)
+}
+
+// label returns the name of the matched permission if allowed,
+// or of the first defined required permission
+func (c *Collector) label(d permission.Decision) string {
+	if d.Allowed && c.names[d.Match.Name] {
+		return d.Match.Name
+	}
+
+	for _, p := range d.Required {
+		if c.names[p.Name] {
+			return p.Name
+		}
+	}
+
+	return Other
+}
+
+// Definitions wraps Definitions and records the metrics of its evaluations.
+// Decisions are also reported to the hooks registered with permission.OnDecision,
+// a Collector must not be registered there as well or decisions would be counted twice
+type Definitions struct {
+	defs      permission.Definitions
+	collector *Collector
+}
+
+// Instrument returns the definitions wrapped with a Collector using the sink
+func Instrument(defs permission.Definitions, sink Sink) *Definitions {
+	return &Definitions{defs: defs, collector: NewCollector(defs, sink)}
+}
+
+// Definitions returns the wrapped definitions, their evaluations are not recorded
+func (d *Definitions) Definitions() permission.Definitions {
+	return d.defs
+}
+
+// Decide evaluates the required permissions against the scope and records the decision
+func (d *Definitions) Decide(ctx context.Context, subject string, required, scope permission.Scope) permission.Decision {
+	dec := d.defs.Decide(ctx, subject, required, scope)
+	d.collector.HandleDecision(ctx, dec)
+	return dec
+}
+
+// RequireScope checks wether the given scope matches one of the required permissions
+// and records the decision
+func (d *Definitions) RequireScope(required, scope permission.Scope) bool {
+	return d.Decide(context.Background(), "", required, scope).Allowed
+}
+
+// Require parses the required permissions and the scope, checks wether the scope
+// matches one of the required permissions and records the decision.
+// Returns false if the parsing fails
+func (d *Definitions) Require(required, scope string) bool {
+	req, err := permission.ParseScope(required)
+	if err != nil {
+		d.fail(context.Background(), "", nil, err)
+		return false
+	}
+
+	s, err := permission.ParseScope(scope)
+	if err != nil {
+		d.fail(context.Background(), "", req, err)
+		return false
+	}
+
+	return d.RequireScope(req, s)
+}
+
+// RequireGrants is like permission.Definitions.RequireGrants and records the decision
+func (d *Definitions) RequireGrants(required string, g permission.Grants, clock permission.Clock) bool {
+	return d.RequireGrantsWith(required, g, clock, nil)
+}
+
+// RequireGrantsWith is like permission.Definitions.RequireGrantsWith and records the decision
+func (d *Definitions) RequireGrantsWith(required string, g permission.Grants, clock permission.Clock, attrs permission.Attributes) bool {
+	req, err := permission.ParseScope(required)
+	if err != nil {
+		d.fail(context.Background(), "", nil, err)
+		return false
+	}
+
+	return d.RequireScope(req, g.Active(clock).Satisfied(attrs).Scope())
+}
+
+// RequireGroups is like permission.Definitions.RequireGroups and records the decision
+func (d *Definitions) RequireGroups(required string, g *permission.Groups, subject string, direct permission.Scope) bool {
+	req, err := permission.ParseScope(required)
+	if err != nil {
+		d.fail(context.Background(), subject, nil, err)
+		return false
+	}
+
+	return d.Decide(context.Background(), subject, req, g.Resolve(subject, direct)).Allowed
+}
+
+// fail notifies the decision hooks of the failed evaluation and records it
+func (d *Definitions) fail(ctx context.Context, subject string, required permission.Scope, err error) {
+	dec := permission.Fail(ctx, subject, err)
+	dec.Required = required
+	d.collector.HandleDecision(ctx, dec)
+}
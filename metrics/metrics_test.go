@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSink struct {
+	counts    map[string]int
+	durations map[string][]time.Duration
+}
+
+func newFakeSink() *fakeSink {
+	return &fakeSink{counts: make(map[string]int), durations: make(map[string][]time.Duration)}
+}
+
+func (f *fakeSink) Count(name, result string) {
+	f.counts[name+" "+result]++
+}
+
+func (f *fakeSink) Observe(name string, d time.Duration) {
+	f.durations[name] = append(f.durations[name], d)
+}
+
+var testDefs = permission.Definitions{
+	{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+	{Name: "playlist", Subset: []string{"edit"}},
+}
+
+func TestInstrument(t *testing.T) {
+	sink := newFakeSink()
+	defs := Instrument(testDefs, sink)
+
+	assert.True(t, defs.Require("user.profile", "user"))
+	assert.True(t, defs.Require("user.edit,playlist.edit", "playlist.edit"))
+	assert.False(t, defs.Require("user.edit", "user"))
+	assert.False(t, defs.Require("repo.write", "repo.write"))
+	assert.False(t, defs.Require("users.edit", "users.edit"))
+	assert.False(t, defs.Require("user.", "user"))
+	assert.False(t, defs.Require("user.edit", "user.edit,"))
+
+	assert.Equal(t, map[string]int{
+		"user allow":     1,
+		"playlist allow": 1,
+		"user deny":      1,
+		"other deny":     2,
+		"other error":    1,
+		"user error":     1,
+	}, sink.counts)
+	assert.Len(t, sink.durations["user"], 3)
+	assert.Len(t, sink.durations["other"], 3)
+
+	d := defs.Decide(context.Background(), "alice", permission.Scope{{Name: "playlist", Sub: "edit"}}, nil)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 1, sink.counts["playlist deny"])
+
+	assert.Equal(t, testDefs, defs.Definitions())
+}
+
+func TestInstrumentEvaluations(t *testing.T) {
+	var decisions []permission.Decision
+	permission.OnDecision(permission.DecisionHookFunc(func(ctx context.Context, d permission.Decision) {
+		decisions = append(decisions, d)
+	}))
+	defer permission.OnDecision()
+
+	sink := newFakeSink()
+	defs := Instrument(testDefs, sink)
+
+	grants := permission.Grants{{Permission: permission.Permission{Name: "playlist", Sub: "edit"}}}
+	assert.True(t, defs.RequireGrants("playlist.edit", grants, permission.SystemClock))
+	assert.False(t, defs.RequireGrantsWith("user.edit", grants, permission.SystemClock, nil))
+
+	g := permission.NewGroups()
+	require.NoError(t, g.Grant("team", permission.Scope{{Name: "user"}}))
+	require.NoError(t, g.AddMember("team", "alice"))
+	assert.True(t, defs.RequireGroups("user.profile", g, "alice", nil))
+	assert.False(t, defs.RequireGroups("user.", g, "alice", nil))
+
+	// failures are reported to the hooks as well
+	assert.False(t, defs.Require("user.edit", "user,"))
+
+	assert.Equal(t, map[string]int{
+		"playlist allow": 1,
+		"user deny":      1,
+		"user allow":     1,
+		"other error":    1,
+		"user error":     1,
+	}, sink.counts)
+
+	require.Len(t, decisions, 5)
+	assert.Equal(t, "alice", decisions[3].Subject)
+	assert.Equal(t, permission.ReasonError, decisions[3].Reason)
+	assert.Equal(t, permission.ReasonError, decisions[4].Reason)
+}
+
+func TestCollectorHook(t *testing.T) {
+	sink := newFakeSink()
+	permission.OnDecision(NewCollector(testDefs, sink))
+	defer permission.OnDecision()
+
+	for i := 0; i < 100; i++ {
+		testDefs.Require(fmt.Sprintf("name%d.edit", i), "user")
+	}
+	testDefs.Require("user.profile", "user.profile")
+
+	// labels are bounded to the defined names
+	assert.Equal(t, map[string]int{"other deny": 100, "user allow": 1}, sink.counts)
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus is a Sink exposing the metrics to Prometheus:
+// a permission_decisions_total counter and a permission_decision_duration_seconds histogram,
+// both labeled by permission, and result for the counter
+type Prometheus struct {
+	decisions *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+}
+
+// NewPrometheus registers the metrics with the registerer.
+// Buckets default to prometheus.DefBuckets if nil
+func NewPrometheus(reg prometheus.Registerer, buckets []float64) (*Prometheus, error) {
+	p := Prometheus{
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "permission",
+			Name:      "decisions_total",
+			Help:      "Number of authorization decisions by permission and result.",
+		}, []string{"permission", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "permission",
+			Name:      "decision_duration_seconds",
+			Help:      "Duration of authorization decisions by permission.",
+			Buckets:   buckets,
+		}, []string{"permission"}),
+	}
+
+	for _, c := range []prometheus.Collector{p.decisions, p.latency} {
+		err := reg.Register(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &p, nil
+}
+
+// Count increments the permission_decisions_total counter
+func (p *Prometheus) Count(name, result string) {
+	p.decisions.WithLabelValues(name, result).Inc()
+}
+
+// Observe records the duration in the permission_decision_duration_seconds histogram
+func (p *Prometheus) Observe(name string, d time.Duration) {
+	p.latency.WithLabelValues(name).Observe(d.Seconds())
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	p, err := NewPrometheus(reg, []float64{0.001, 0.01})
+	require.NoError(t, err)
+
+	p.Count("user", Allow)
+	p.Count("user", Allow)
+	p.Count("user", Deny)
+	p.Observe("user", 2*time.Millisecond)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP permission_decisions_total Number of authorization decisions by permission and result.
+# TYPE permission_decisions_total counter
+permission_decisions_total{permission="user",result="allow"} 2
+permission_decisions_total{permission="user",result="deny"} 1
+# HELP permission_decision_duration_seconds Duration of authorization decisions by permission.
+# TYPE permission_decision_duration_seconds histogram
+permission_decision_duration_seconds_bucket{permission="user",le="0.001"} 0
+permission_decision_duration_seconds_bucket{permission="user",le="0.01"} 1
+permission_decision_duration_seconds_bucket{permission="user",le="+Inf"} 1
+permission_decision_duration_seconds_sum{permission="user"} 0.002
+permission_decision_duration_seconds_count{permission="user"} 1
+`))
+	assert.NoError(t, err)
+
+	_, err = NewPrometheus(reg, nil)
+	assert.Error(t, err)
+}
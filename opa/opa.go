@@ -0,0 +1,141 @@
+// Package opa exports definitions, roles and grants as a data document
+// that Open Policy Agent policies can query.
+//
+// The document is stored under the permission key:
+//
+//	{
+//	  "permission": {
+//	    "version": 1,
+//	    "definitions": {"user": {"subset": ["edit", "profile"], "defaultSubset": ["profile"]}},
+//	    "roles": {"admin": [{"name": "user", "sub": "edit"}]},
+//	    "subjects": {"alice": {"grants": [{"name": "user", "sub": ""}], "roles": ["admin"]}}
+//	  }
+//	}
+//
+// Permissions are objects so that policies don't depend on the delimiter.
+// Policy is a reference Rego policy implementing the semantics of Definitions.RequireScope.
+package opa
+
+import (
+	"context"
+	_ "embed" // embeds the reference policy
+	"encoding/json"
+	"io"
+
+	"github.com/asdine/permission"
+)
+
+// Version of the data document schema
+const Version = 1
+
+// Policy is the reference Rego policy.
+// It expects an input like {"subject": "alice", "required": [{"name": "user", "sub": "edit"}]}
+// and defines data.permission.allow
+//
+//go:embed permission.rego
+var Policy string
+
+// Data is the data document
+type Data struct {
+	Version     int                     `json:"version"`
+	Definitions map[string]Definition   `json:"definitions"`
+	Roles       map[string][]Permission `json:"roles"`
+	Subjects    map[string]Subject      `json:"subjects"`
+}
+
+// Definition of a permission
+type Definition struct {
+	Subset        []string `json:"subset"`
+	DefaultSubset []string `json:"defaultSubset"`
+}
+
+// Permission with an empty sub if it only has a name
+type Permission struct {
+	Name string `json:"name"`
+	Sub  string `json:"sub"`
+}
+
+// Subject lists the grants and the roles of a subject
+type Subject struct {
+	Grants []Permission `json:"grants"`
+	Roles  []string     `json:"roles"`
+}
+
+// Export builds the data document from the definitions, the roles
+// and a consistent snapshot of the store
+func Export(ctx context.Context, store permission.Store, defs permission.Definitions, roles permission.Roles) (*Data, error) {
+	d := Data{
+		Version:     Version,
+		Definitions: make(map[string]Definition),
+		Roles:       make(map[string][]Permission),
+		Subjects:    make(map[string]Subject),
+	}
+
+	for _, def := range defs {
+		d.Definitions[def.Name] = Definition{
+			Subset:        nonNil(def.Subset),
+			DefaultSubset: nonNil(def.DefaultSubset),
+		}
+	}
+
+	for _, r := range roles {
+		d.Roles[r.Name] = permissions(r.Scope)
+	}
+
+	err := store.View(ctx, func(r permission.StoreReader) error {
+		subjects, err := r.Subjects(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, subject := range subjects {
+			s, err := r.ScopeOf(ctx, subject)
+			if err != nil {
+				return err
+			}
+
+			assigned, err := r.RolesOf(ctx, subject)
+			if err != nil {
+				return err
+			}
+
+			d.Subjects[subject] = Subject{Grants: permissions(s), Roles: nonNil(assigned)}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// WriteTo writes the data document under the permission key as indented JSON
+func (d *Data) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(map[string]*Data{"permission": d}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+func permissions(s permission.Scope) []Permission {
+	sorted := append(permission.Scope{}, s...)
+	permission.SortScope(sorted)
+
+	list := make([]Permission, len(sorted))
+	for i, p := range sorted {
+		list[i] = Permission{Name: p.Name, Sub: p.Sub}
+	}
+	return list
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
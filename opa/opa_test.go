@@ -0,0 +1,158 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var testDefs = permission.Definitions{
+	{
+		Name:          "user",
+		Subset:        []string{"edit", "profile", "email", "friends", "about"},
+		DefaultSubset: []string{"profile", "about"},
+	},
+	{
+		Name:          "playlist",
+		Subset:        []string{"edit", "share", "read"},
+		DefaultSubset: []string{"read", "share"},
+	},
+	{
+		Name: "admin",
+	},
+}
+
+var testRoles = permission.Roles{
+	{Name: "editor", Scope: permission.Scope{{Name: "playlist", Sub: "edit"}, {Name: "user", Sub: "edit"}}},
+}
+
+func testStore(t *testing.T) permission.Store {
+	ctx := context.Background()
+	store := permission.NewMemoryStore()
+
+	grants := map[string]string{
+		"alice": "user",
+		"bob":   "user.email,playlist",
+		"carol": "playlist.read,admin",
+		"erin":  "user.unknown,users.edit",
+	}
+	for subject, repr := range grants {
+		s, err := permission.ParseScope(repr)
+		require.NoError(t, err)
+		require.NoError(t, store.Grant(ctx, subject, s))
+	}
+	require.NoError(t, store.Assign(ctx, "bob", "editor"))
+	require.NoError(t, store.Assign(ctx, "dave", "editor", "unknown"))
+
+	return store
+}
+
+// decision is a row of the golden decision table shared with the Rego policy
+type decision struct {
+	Subject  string       `json:"subject"`
+	Required []Permission `json:"required"`
+	Allow    bool         `json:"allow"`
+}
+
+func golden(t *testing.T, name string, actual []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, actual, 0644))
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestExport(t *testing.T) {
+	d, err := Export(context.Background(), testStore(t), testDefs, testRoles)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = d.WriteTo(&buf)
+	require.NoError(t, err)
+	golden(t, "data.json", buf.Bytes())
+}
+
+func TestDecisions(t *testing.T) {
+	ctx := context.Background()
+	store := testStore(t)
+
+	subjects := []string{"alice", "bob", "carol", "dave", "erin", "nobody"}
+	required := []string{
+		"user", "user.edit", "user.profile", "user.email", "user.friends", "user.unknown",
+		"playlist", "playlist.edit", "playlist.read",
+		"admin", "admin.edit", "users.edit",
+		"user.edit,playlist.share",
+	}
+
+	var table []decision
+	for _, subject := range subjects {
+		s, err := permission.EffectiveScope(ctx, store, testRoles, subject)
+		require.NoError(t, err)
+
+		for _, repr := range required {
+			req, err := permission.ParseScope(repr)
+			require.NoError(t, err)
+
+			table = append(table, decision{
+				Subject:  subject,
+				Required: permissions(req),
+				Allow:    testDefs.RequireScope(req, s),
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(table, "", "  ")
+	require.NoError(t, err)
+	golden(t, "decisions.json", append(data, '\n'))
+}
+
+// TestPolicy evaluates the Rego policy against the exported data
+// and checks it makes the decisions of the Go implementation
+func TestPolicy(t *testing.T) {
+	assert.True(t, strings.HasPrefix(Policy, "# Reference policy"))
+
+	raw, err := os.ReadFile("testdata/data.json")
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &data))
+
+	raw, err = os.ReadFile("testdata/decisions.json")
+	require.NoError(t, err)
+	var table []decision
+	require.NoError(t, json.Unmarshal(raw, &table))
+	require.NotEmpty(t, table)
+
+	ctx := context.Background()
+	query, err := rego.New(
+		rego.Query("data.permission.allow"),
+		rego.Module("permission.rego", Policy),
+		rego.Store(inmem.NewFromObject(data)),
+	).PrepareForEval(ctx)
+	require.NoError(t, err)
+
+	for _, row := range table {
+		rs, err := query.Eval(ctx, rego.EvalInput(map[string]interface{}{
+			"subject":  row.Subject,
+			"required": row.Required,
+		}))
+		require.NoError(t, err)
+		require.Len(t, rs, 1)
+		assert.Equal(t, row.Allow, rs[0].Expressions[0].Value, "%s %v", row.Subject, row.Required)
+	}
+}
@@ -0,0 +1,64 @@
+# Reference policy for the data document exported by github.com/asdine/permission/opa.
+#
+# Input:
+#   {"subject": "alice", "required": [{"name": "user", "sub": "edit"}]}
+#
+# allow is true if the subject, directly or through its roles, is granted
+# one of the required permissions, following the semantics of Definition.Allowed.
+package permission
+
+import rego.v1
+
+default allow := false
+
+allow if {
+	some required in input.required
+	defined(required)
+	some given in granted
+	allowed(required, given)
+}
+
+granted contains given if {
+	some given in data.permission.subjects[input.subject].grants
+}
+
+granted contains given if {
+	some role in data.permission.subjects[input.subject].roles
+	some given in data.permission.roles[role]
+}
+
+# defined is true if the permission matches its definition
+defined(p) if {
+	data.permission.definitions[p.name]
+	p.sub == ""
+}
+
+defined(p) if {
+	p.sub in data.permission.definitions[p.name].subset
+}
+
+allowed(required, given) if {
+	required.name == given.name
+	required.sub == ""
+	given.sub == ""
+}
+
+allowed(required, given) if {
+	required.name == given.name
+	required.sub == ""
+	given.sub in data.permission.definitions[required.name].defaultSubset
+}
+
+allowed(required, given) if {
+	required.name == given.name
+	required.sub != ""
+	required.sub == given.sub
+	given.sub in data.permission.definitions[required.name].subset
+}
+
+allowed(required, given) if {
+	required.name == given.name
+	required.sub != ""
+	given.sub == ""
+	required.sub in data.permission.definitions[required.name].defaultSubset
+}
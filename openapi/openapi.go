@@ -0,0 +1,243 @@
+// Package openapi keeps the OAuth2 scopes of OpenAPI 3 documents aligned with definitions.
+//
+// Scopes generates the scopes map of an OAuth2 security scheme from definitions,
+// and Check reports the scopes required by the operations of a document
+// that can't be parsed, aren't defined or aren't declared by their security scheme,
+// and the security schemes they use that the document doesn't declare.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asdine/permission"
+	"gopkg.in/yaml.v3"
+)
+
+// Errors
+var (
+	ErrUndeclared    = errors.New("The scope is not declared by the security scheme")
+	ErrUnknownScheme = errors.New("The security scheme is not declared by the document")
+)
+
+// Scopes returns the scopes map of an OAuth2 flow, listing every name and sub of the definitions
+// with their description.
+// A name without description lists the subs of its default subset instead
+func Scopes(defs permission.Definitions) map[string]string {
+	scopes := make(map[string]string)
+
+	for _, def := range defs {
+		desc := def.Description
+		if desc == "" && len(def.DefaultSubset) > 0 {
+			implied := make([]string, len(def.DefaultSubset))
+			for i, sub := range def.DefaultSubset {
+				implied[i] = permission.Permission{Name: def.Name, Sub: sub}.String()
+			}
+			desc = "Implies " + strings.Join(implied, ", ")
+		}
+		scopes[def.Name] = desc
+
+		for _, sub := range def.Subset {
+			scopes[permission.Permission{Name: def.Name, Sub: sub}.String()] = def.SubDescriptions[sub]
+		}
+	}
+
+	return scopes
+}
+
+// Document is the part of an OpenAPI 3 document describing the security of the operations
+type Document struct {
+	Security   []SecurityRequirement `yaml:"security"`
+	Paths      map[string]PathItem   `yaml:"paths"`
+	Components struct {
+		SecuritySchemes map[string]SecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+// SecurityRequirement maps the name of a security scheme to the required scopes
+type SecurityRequirement map[string][]string
+
+// SecurityScheme of a document
+type SecurityScheme struct {
+	Type  string          `yaml:"type"`
+	Flows map[string]Flow `yaml:"flows"`
+}
+
+// Flow of an OAuth2 security scheme
+type Flow struct {
+	AuthorizationURL string            `yaml:"authorizationUrl,omitempty"`
+	TokenURL         string            `yaml:"tokenUrl,omitempty"`
+	RefreshURL       string            `yaml:"refreshUrl,omitempty"`
+	Scopes           map[string]string `yaml:"scopes"`
+}
+
+// PathItem lists the operations of a path
+type PathItem struct {
+	Get     *Operation `yaml:"get"`
+	Put     *Operation `yaml:"put"`
+	Post    *Operation `yaml:"post"`
+	Delete  *Operation `yaml:"delete"`
+	Options *Operation `yaml:"options"`
+	Head    *Operation `yaml:"head"`
+	Patch   *Operation `yaml:"patch"`
+	Trace   *Operation `yaml:"trace"`
+}
+
+// Operations returns the operations of the path item indexed by their method, in upper case
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch, "TRACE": p.Trace,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// Operation of a path.
+// A nil Security means the operation uses the security of the document
+type Operation struct {
+	OperationID string                `yaml:"operationId"`
+	Security    []SecurityRequirement `yaml:"security"`
+}
+
+// Parse decodes an OpenAPI 3 document, in JSON or YAML
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Problem is a scope of a document that doesn't match the definitions,
+// or a security scheme the document doesn't declare
+type Problem struct {
+	// Path and Method of the operation, empty for the security of the document
+	Path        string
+	Method      string
+	OperationID string
+
+	Scheme string
+	// Scope is empty if the scheme is not declared
+	Scope string
+	Err   error
+}
+
+func (p Problem) Error() string {
+	where := "security"
+	if p.Path != "" {
+		where = p.Method + " " + p.Path
+		if p.OperationID != "" {
+			where += " (" + p.OperationID + ")"
+		}
+	}
+	if p.Scope == "" {
+		return fmt.Sprintf("%s: %s: %s", where, p.Scheme, p.Err)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", where, p.Scheme, p.Scope, p.Err)
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// Check returns the problems of the scopes required by the document and its operations, sorted by path and method.
+// A requirement naming a security scheme the document doesn't declare is reported with ErrUnknownScheme.
+// Security schemes declared by the document with a type other than oauth2 or openIdConnect are ignored
+func Check(doc *Document, defs permission.Definitions) []Problem {
+	var problems []Problem
+
+	check := func(path, method, id string, security []SecurityRequirement) {
+		for _, req := range security {
+			schemes := make([]string, 0, len(req))
+			for name := range req {
+				schemes = append(schemes, name)
+			}
+			sort.Strings(schemes)
+
+			for _, name := range schemes {
+				scheme, declared := doc.Components.SecuritySchemes[name]
+				if !declared {
+					problems = append(problems, Problem{
+						Path:        path,
+						Method:      method,
+						OperationID: id,
+						Scheme:      name,
+						Err:         ErrUnknownScheme,
+					})
+					continue
+				}
+				if scheme.Type != "oauth2" && scheme.Type != "openIdConnect" {
+					continue
+				}
+
+				for _, scope := range req[name] {
+					err := checkScope(defs, scheme, scope)
+					if err != nil {
+						problems = append(problems, Problem{
+							Path:        path,
+							Method:      method,
+							OperationID: id,
+							Scheme:      name,
+							Scope:       scope,
+							Err:         err,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	check("", "", "", doc.Security)
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+		ops := item.Operations()
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			check(path, method, ops[method].OperationID, ops[method].Security)
+		}
+	}
+
+	return problems
+}
+
+func checkScope(defs permission.Definitions, scheme SecurityScheme, scope string) error {
+	p, err := permission.Parse(scope)
+	if err != nil {
+		return err
+	}
+
+	if defs.Definition(p) == nil {
+		return permission.ErrUndefined
+	}
+
+	if scheme.Type != "oauth2" {
+		return nil
+	}
+
+	for _, flow := range scheme.Flows {
+		if _, ok := flow.Scopes[scope]; ok {
+			return nil
+		}
+	}
+	return ErrUndeclared
+}
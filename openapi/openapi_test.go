@@ -0,0 +1,96 @@
+package openapi
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDefinitions(t *testing.T) permission.Definitions {
+	data, err := os.ReadFile("testdata/definitions.json")
+	require.NoError(t, err)
+
+	defs, err := permission.LoadDefinitions(data)
+	require.NoError(t, err)
+	return defs
+}
+
+func TestScopes(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"user":          "Access to the user account",
+		"user.edit":     "Edit the profile",
+		"user.profile":  "Read the public profile",
+		"user.email":    "",
+		"playlist":      "Implies playlist.read",
+		"playlist.edit": "",
+		"playlist.read": "",
+	}, Scopes(testDefinitions(t)))
+
+	assert.Equal(t, map[string]string{"admin": ""}, Scopes(permission.Definitions{{Name: "admin"}}))
+}
+
+func TestParse(t *testing.T) {
+	data, err := os.ReadFile("testdata/spec.yaml")
+	require.NoError(t, err)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, []SecurityRequirement{{"oauth": {"user"}}}, doc.Security)
+	assert.Len(t, doc.Paths, 4)
+	assert.Equal(t, "oauth2", doc.Components.SecuritySchemes["oauth"].Type)
+	assert.Equal(t, "https://example.com/token", doc.Components.SecuritySchemes["oauth"].Flows["authorizationCode"].TokenURL)
+
+	item := doc.Paths["/me"]
+	ops := item.Operations()
+	assert.Len(t, ops, 2)
+	assert.Equal(t, "updateMe", ops["PATCH"].OperationID)
+	assert.Nil(t, ops["GET"].Security)
+
+	doc, err = Parse([]byte(`{"paths": {"/me": {"get": {"security": [{"oauth": ["user"]}]}}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []SecurityRequirement{{"oauth": {"user"}}}, doc.Paths["/me"].Get.Security)
+
+	_, err = Parse([]byte("paths: ["))
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	data, err := os.ReadFile("testdata/spec.yaml")
+	require.NoError(t, err)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+
+	problems := Check(doc, testDefinitions(t))
+	require.Len(t, problems, 4)
+
+	assert.Equal(t, Problem{Path: "/me/email", Method: "GET", OperationID: "getEmail", Scheme: "oauth", Scope: "user.email", Err: ErrUndeclared}, problems[0])
+	assert.Equal(t, "GET /me/email (getEmail): oauth: user.email: The scope is not declared by the security scheme", problems[0].Error())
+
+	assert.Equal(t, Problem{Path: "/playlists", Method: "GET", Scheme: "oauth", Scope: "playlist.list", Err: permission.ErrUndefined}, problems[1])
+	assert.Equal(t, "GET /playlists: oauth: playlist.list: The permission is not defined", problems[1].Error())
+
+	assert.Equal(t, "oauth", problems[2].Scheme)
+	assert.True(t, errors.Is(problems[2], permission.ErrBadFormat))
+	var err2 error = problems[2]
+	assert.True(t, errors.Is(err2, permission.ErrBadFormat))
+	assert.Equal(t, "createPlaylist", problems[2].OperationID)
+
+	// schemes that aren't declared are reported once, without checking their scopes
+	assert.Equal(t, Problem{Path: "/playlists", Method: "POST", OperationID: "createPlaylist", Scheme: "openid", Err: ErrUnknownScheme}, problems[3])
+	assert.Equal(t, "POST /playlists (createPlaylist): openid: The security scheme is not declared by the document", problems[3].Error())
+
+	doc.Security = []SecurityRequirement{{"oauth": {"nope"}}}
+	problems = Check(doc, testDefinitions(t))
+	require.Len(t, problems, 5)
+	assert.Equal(t, "security: oauth: nope: The permission is not defined", problems[0].Error())
+
+	// documents without components declare no schemes
+	doc, err = Parse([]byte(`{"security": [{"oauth": []}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Problem{{Scheme: "oauth", Err: ErrUnknownScheme}}, Check(doc, testDefinitions(t)))
+}
@@ -2,7 +2,6 @@
 package permission
 
 import (
-	"fmt"
 	"strings"
 	"sync"
 )
@@ -19,14 +18,24 @@ func Delimiter(delim string) {
 	delimiter = delim
 }
 
-// Parse takes a string representation and returns the corresponding Permission
+// Parse takes a string representation and returns the corresponding Permission.
+// It doesn't allocate, the name and the sub share the memory of repr
 func Parse(repr string) (Permission, error) {
-	p := Permission{}
-	err := p.UnmarshalText([]byte(repr))
-	if err != nil {
-		return Permission{}, err
+	if len(repr) == 0 {
+		return Permission{}, ErrEmptyInput
+	}
+
+	i := strings.Index(repr, delimiter)
+	if i < 0 || delimiter == "" {
+		return Permission{Name: repr}, nil
+	}
+
+	name, sub := repr[:i], repr[i+len(delimiter):]
+	if name == "" || sub == "" || strings.Contains(sub, delimiter) {
+		return Permission{}, ErrBadFormat
 	}
-	return p, nil
+
+	return Permission{Name: name, Sub: sub}, nil
 }
 
 // Permission is a simple permission structure.
@@ -41,7 +50,9 @@ type Permission struct {
 	Sub string
 }
 
-// Equal reports whether p and q represents the same permission
+// Equal reports whether p and q represents the same permission.
+// Go compares strings that share their memory without reading them,
+// so two permissions interned by the same Registry compare in constant time
 func (p Permission) Equal(q Permission) bool {
 	return p.Name == q.Name && p.Sub == q.Sub
 }
@@ -53,15 +64,22 @@ func (p Permission) IsZero() bool {
 
 // MarshalText implements the encoding.TextMarshaler interface
 func (p Permission) MarshalText() (text []byte, err error) {
+	return p.AppendText(nil)
+}
+
+// AppendText appends the text representation of the permission to b and returns the extended buffer.
+// It implements the encoding.TextAppender interface and doesn't allocate if b is large enough
+func (p Permission) AppendText(b []byte) ([]byte, error) {
 	if p.Name == "" {
-		return nil, ErrEmptyName
+		return b, ErrEmptyName
 	}
 
-	if p.Sub == "" {
-		return []byte(p.Name), nil
+	b = append(b, p.Name...)
+	if p.Sub != "" {
+		b = append(b, delimiter...)
+		b = append(b, p.Sub...)
 	}
-
-	return []byte(fmt.Sprintf("%s%s%s", p.Name, delimiter, p.Sub)), nil
+	return b, nil
 }
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface
@@ -70,30 +88,19 @@ func (p *Permission) UnmarshalText(text []byte) error {
 		return ErrEmptyInput
 	}
 
-	perm := string(text)
-	if !strings.Contains(perm, delimiter) {
-		p.Name = perm
-		return nil
-	}
-
-	frags := strings.Split(perm, delimiter)
-	if len(frags) != 2 {
-		return ErrBadFormat
-	}
-
-	if frags[0] == "" || frags[1] == "" {
-		return ErrBadFormat
+	perm, err := Parse(string(text))
+	if err != nil {
+		return err
 	}
 
-	p.Name = frags[0]
-	p.Sub = frags[1]
+	*p = perm
 	return nil
 }
 
 // String returns the string representation of the Permission
 func (p Permission) String() string {
 	if p.Sub != "" {
-		return fmt.Sprintf("%s%s%s", p.Name, delimiter, p.Sub)
+		return p.Name + delimiter + p.Sub
 	}
 
 	return p.Name
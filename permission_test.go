@@ -204,3 +204,97 @@ func TestEqual(t *testing.T) {
 	r := Permission{Name: "a", Sub: "b"}
 	assert.True(t, p.Equal(r))
 }
+
+func TestPermissionAppendText(t *testing.T) {
+	b := []byte("scope=")
+
+	b, err := Permission{Name: "a", Sub: "b"}.AppendText(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "scope=a.b", string(b))
+
+	b, err = Permission{Name: "c"}.AppendText(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "scope=a.bc", string(b))
+
+	b, err = Permission{Sub: "d"}.AppendText(b)
+	assert.Equal(t, ErrEmptyName, err)
+	assert.Equal(t, "scope=a.bc", string(b))
+
+	Delimiter("::")
+	defer Delimiter(".")
+	assert.Equal(t, "a::b", Permission{Name: "a", Sub: "b"}.String())
+
+	p, err := Parse("a::b")
+	assert.NoError(t, err)
+	assert.Equal(t, Permission{Name: "a", Sub: "b"}, p)
+
+	_, err = Parse("a::b::c")
+	assert.Equal(t, ErrBadFormat, err)
+}
+
+func TestPermissionAllocs(t *testing.T) {
+	var p Permission
+	var err error
+	buf := make([]byte, 0, 64)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		p, err = Parse("user.edit")
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		buf, err = p.AppendText(buf[:0])
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		err = p.UnmarshalText(buf)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), allocs)
+
+	var str string
+	allocs = testing.AllocsPerRun(100, func() {
+		str = p.String()
+	})
+	assert.Equal(t, "user.edit", str)
+	assert.LessOrEqual(t, allocs, float64(1))
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = Parse("user.edit")
+	}
+}
+
+func BenchmarkPermissionUnmarshalText(b *testing.B) {
+	text := []byte("user.edit")
+	var p Permission
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = p.UnmarshalText(text)
+	}
+}
+
+func BenchmarkPermissionAppendText(b *testing.B) {
+	p := Permission{Name: "user", Sub: "edit"}
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = p.AppendText(buf[:0])
+	}
+}
+
+func BenchmarkPermissionString(b *testing.B) {
+	p := Permission{Name: "user", Sub: "edit"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = p.String()
+	}
+}
@@ -0,0 +1,185 @@
+package policy
+
+import (
+	"sync"
+
+	"github.com/asdine/permission"
+)
+
+// Result of an evaluation
+type Result int
+
+// Results
+const (
+	// NotApplicable means no statement applies to the request
+	NotApplicable Result = iota
+	Allowed
+	Denied
+)
+
+func (r Result) String() string {
+	switch r {
+	case Allowed:
+		return "allow"
+	case Denied:
+		return "deny"
+	}
+	return "not-applicable"
+}
+
+// Request is evaluated against the policies attached to the subject and its roles
+type Request struct {
+	Subject string
+	Roles   []string
+
+	Permission permission.Permission
+	Resource   string
+
+	// Attributes used by the statements conditions
+	Attributes permission.Attributes
+}
+
+// Decision is the result of an evaluation and the statement that decided it
+type Decision struct {
+	Result Result
+
+	// Document and Statement are nil if the result is NotApplicable
+	Document  *Document
+	Statement *Statement
+}
+
+// Evaluator evaluates requests against the policies attached to subjects and roles.
+// A deny statement overrides any allow statement.
+// It is safe for concurrent use
+type Evaluator struct {
+	defs   permission.Definitions
+	schema permission.Schema
+
+	mu       sync.RWMutex
+	subjects map[string][]*Document
+	roles    map[string][]*Document
+}
+
+// NewEvaluator returns an Evaluator that validates the attached documents
+// against the definitions and the schema
+func NewEvaluator(defs permission.Definitions, schema permission.Schema) *Evaluator {
+	return &Evaluator{
+		defs:     defs,
+		schema:   schema,
+		subjects: make(map[string][]*Document),
+		roles:    make(map[string][]*Document),
+	}
+}
+
+// AttachToSubject validates the document and attaches it to the subject
+func (e *Evaluator) AttachToSubject(subject string, doc *Document) error {
+	if subject == "" {
+		return permission.ErrEmptySubject
+	}
+
+	return e.attach(e.subjects, subject, doc)
+}
+
+// AttachToRole validates the document and attaches it to the role
+func (e *Evaluator) AttachToRole(role string, doc *Document) error {
+	if role == "" {
+		return permission.ErrEmptyRole
+	}
+
+	return e.attach(e.roles, role, doc)
+}
+
+// DetachFromSubject removes the document from the subject
+func (e *Evaluator) DetachFromSubject(subject string, doc *Document) {
+	e.detach(e.subjects, subject, doc)
+}
+
+// DetachFromRole removes the document from the role
+func (e *Evaluator) DetachFromRole(role string, doc *Document) {
+	e.detach(e.roles, role, doc)
+}
+
+func (e *Evaluator) attach(m map[string][]*Document, key string, doc *Document) error {
+	err := doc.Validate(e.defs, e.schema)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, d := range m[key] {
+		if d == doc {
+			return nil
+		}
+	}
+	m[key] = append(m[key], doc)
+	return nil
+}
+
+func (e *Evaluator) detach(m map[string][]*Document, key string, doc *Document) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var kept []*Document
+	for _, d := range m[key] {
+		if d != doc {
+			kept = append(kept, d)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(m, key)
+	} else {
+		m[key] = kept
+	}
+}
+
+// Evaluate returns Denied if a deny statement applies to the request,
+// Allowed if an allow statement applies and NotApplicable otherwise.
+// An allow statement whose condition fails to evaluate doesn't apply but a deny statement does,
+// so that missing or malformed attributes can't remove denies.
+// Requests for permissions that are not defined are NotApplicable
+func (e *Evaluator) Evaluate(req Request) Decision {
+	def := e.defs.Definition(req.Permission)
+	if def == nil {
+		return Decision{}
+	}
+
+	e.mu.RLock()
+	docs := append([]*Document{}, e.subjects[req.Subject]...)
+	for _, role := range req.Roles {
+		docs = append(docs, e.roles[role]...)
+	}
+	e.mu.RUnlock()
+
+	var decision Decision
+	for _, doc := range docs {
+		for i := range doc.Statements {
+			s := &doc.Statements[i]
+			if !s.matchPermission(def, req.Permission) || !s.matchResource(req.Resource) {
+				continue
+			}
+
+			if s.Condition != nil {
+				ok, err := s.Condition.Eval(req.Attributes)
+				if err != nil && s.Effect == Deny {
+					ok = true
+				}
+				if !ok {
+					continue
+				}
+			}
+
+			if s.Effect == Deny {
+				return Decision{Result: Denied, Document: doc, Statement: s}
+			}
+
+			if decision.Result == NotApplicable {
+				decision = Decision{Result: Allowed, Document: doc, Statement: s}
+			}
+		}
+	}
+
+	return decision
+}
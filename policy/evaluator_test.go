@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	editors, err := Parse([]byte(yamlDoc))
+	require.NoError(t, err)
+
+	readers, err := Parse([]byte(`
+id: readers
+statements:
+  - effect: allow
+    permissions: [doc]
+`))
+	require.NoError(t, err)
+
+	blocked, err := Parse([]byte(`
+id: blocked
+statements:
+  - effect: deny
+    permissions: [doc]
+`))
+	require.NoError(t, err)
+
+	e := NewEvaluator(testDefs, nil)
+	require.NoError(t, e.AttachToRole("editor", editors))
+	require.NoError(t, e.AttachToSubject("bob", readers))
+	require.NoError(t, e.AttachToSubject("bob", readers))
+	require.NoError(t, e.AttachToSubject("carol", blocked))
+
+	tests := []struct {
+		name      string
+		req       Request
+		result    Result
+		statement *Statement
+	}{
+		{
+			"allowed through role",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "docs/1"},
+			Allowed, &editors.Statements[0],
+		},
+		{
+			"not applicable without role",
+			Request{Subject: "alice", Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "docs/1"},
+			NotApplicable, nil,
+		},
+		{
+			"resource not matched",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "users/1"},
+			NotApplicable, nil,
+		},
+		{
+			"permission not matched",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "delete"}, Resource: "docs/1"},
+			NotApplicable, nil,
+		},
+		{
+			"deny overrides allow",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "read"}, Resource: "docs/secret/1",
+				Attributes: permission.Attributes{"user.admin": false}},
+			Denied, &editors.Statements[1],
+		},
+		{
+			"deny condition not satisfied",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "read"}, Resource: "docs/secret/1",
+				Attributes: permission.Attributes{"user.admin": true}},
+			Allowed, &editors.Statements[0],
+		},
+		{
+			"deny condition fails to evaluate",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "read"}, Resource: "docs/secret/1"},
+			Denied, &editors.Statements[1],
+		},
+		{
+			"name only statement allows the default subset",
+			Request{Subject: "bob", Permission: permission.Permission{Name: "doc", Sub: "read"}, Resource: "docs/1"},
+			Allowed, &readers.Statements[0],
+		},
+		{
+			"name only statement doesn't allow other subs",
+			Request{Subject: "bob", Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "docs/1"},
+			NotApplicable, nil,
+		},
+		{
+			"deny on a name covers all the subs",
+			Request{Subject: "carol", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "docs/1"},
+			Denied, &blocked.Statements[0],
+		},
+		{
+			"deny on a name covers the name",
+			Request{Subject: "carol", Permission: permission.Permission{Name: "doc"}, Resource: "docs/1"},
+			Denied, &blocked.Statements[0],
+		},
+		{
+			"undefined permission",
+			Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "share"}, Resource: "docs/1"},
+			NotApplicable, nil,
+		},
+	}
+
+	for _, test := range tests {
+		d := e.Evaluate(test.req)
+		assert.Equal(t, test.result, d.Result, test.name)
+		assert.Equal(t, test.statement, d.Statement, test.name)
+	}
+
+	e.DetachFromSubject("carol", blocked)
+	e.DetachFromSubject("bob", readers)
+	d := e.Evaluate(Request{Subject: "bob", Permission: permission.Permission{Name: "doc", Sub: "read"}})
+	assert.Equal(t, NotApplicable, d.Result)
+	assert.Len(t, e.subjects, 0)
+
+	e.DetachFromRole("editor", editors)
+	d = e.Evaluate(Request{Subject: "alice", Roles: []string{"editor"}, Permission: permission.Permission{Name: "doc", Sub: "edit"}, Resource: "docs/1"})
+	assert.Equal(t, NotApplicable, d.Result)
+}
+
+func TestEvaluatorAttach(t *testing.T) {
+	e := NewEvaluator(testDefs, permission.Schema{})
+	doc := &Document{Statements: []Statement{{Effect: Allow, Permissions: []permission.Permission{{Name: "repo"}}}}}
+
+	err := e.AttachToSubject("alice", doc)
+	assert.Equal(t, &StatementError{Index: 0, Err: ErrUnknownPermission}, err)
+	assert.Equal(t, permission.ErrEmptySubject, e.AttachToSubject("", doc))
+	assert.Equal(t, permission.ErrEmptyRole, e.AttachToRole("", doc))
+
+	assert.Equal(t, "allow", Allowed.String())
+	assert.Equal(t, "deny", Denied.String())
+	assert.Equal(t, "not-applicable", NotApplicable.String())
+}
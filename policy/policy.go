@@ -0,0 +1,199 @@
+// Package policy provides declarative policy documents made of allow and deny statements.
+//
+// A statement applies to permissions, written with the name.sub syntax where
+// the sub or the whole permission can be a * wildcard, to resources, which are
+// patterns where * matches any sequence of characters, and optionally to a Condition.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asdine/permission"
+	"gopkg.in/yaml.v3"
+)
+
+// Wildcard matches any name, sub or sequence of characters of a resource
+const Wildcard = "*"
+
+// Errors
+var (
+	ErrBadEffect         = errors.New("The effect must be allow or deny")
+	ErrNoPermissions     = errors.New("The statement has no permissions")
+	ErrUnknownPermission = permission.ErrUndefined
+)
+
+// Effect of a statement
+type Effect string
+
+// Effects
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Statement allows or denies permissions on resources
+type Statement struct {
+	// ID is optional and identifies the statement in decisions
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	Effect Effect `json:"effect" yaml:"effect"`
+
+	Permissions []permission.Permission `json:"permissions" yaml:"permissions"`
+
+	// Resources the statement applies to. The statement applies to every resource if empty
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	// Condition is optional and must be satisfied by the request attributes
+	Condition *permission.Condition `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// Document is a named list of statements
+type Document struct {
+	ID         string      `json:"id,omitempty" yaml:"id,omitempty"`
+	Statements []Statement `json:"statements" yaml:"statements"`
+}
+
+// Parse decodes a JSON or YAML policy document.
+// The input is decoded as JSON if it starts with {
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err := json.Unmarshal(data, &doc)
+		if err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
+
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// StatementError is returned when a statement of a document is not valid
+type StatementError struct {
+	// Index of the statement in the document
+	Index int
+	Err   error
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("statement %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the statement
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that the statements of the document use valid effects,
+// permissions listed in the definitions, and conditions that type check against the schema.
+// Conditions are not checked if the schema is nil
+func (d *Document) Validate(defs permission.Definitions, schema permission.Schema) error {
+	for i := range d.Statements {
+		err := d.Statements[i].Validate(defs, schema)
+		if err != nil {
+			return &StatementError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// Validate checks the statement against the definitions and the schema
+func (s *Statement) Validate(defs permission.Definitions, schema permission.Schema) error {
+	if s.Effect != Allow && s.Effect != Deny {
+		return ErrBadEffect
+	}
+
+	if len(s.Permissions) == 0 {
+		return ErrNoPermissions
+	}
+
+	for _, p := range s.Permissions {
+		if p.Name == Wildcard {
+			if p.Sub != "" {
+				return permission.ErrBadFormat
+			}
+			continue
+		}
+
+		if p.Sub == Wildcard {
+			p.Sub = ""
+		}
+
+		if defs.Definition(p) == nil {
+			return ErrUnknownPermission
+		}
+	}
+
+	if s.Condition != nil && schema != nil {
+		return s.Condition.Check(schema)
+	}
+
+	return nil
+}
+
+// matchPermission reports whether one of the statement permissions covers the required permission
+func (s *Statement) matchPermission(def *permission.Definition, required permission.Permission) bool {
+	for _, p := range s.Permissions {
+		if p.Name == Wildcard || (p.Name == required.Name && p.Sub == Wildcard) {
+			return true
+		}
+
+		// a deny on a name covers all of its subs, not only the default subset
+		if s.Effect == Deny && p.Name == required.Name && p.Sub == "" {
+			return true
+		}
+
+		if def.Allowed(required, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchResource reports whether one of the statement resources matches the resource
+func (s *Statement) matchResource(resource string) bool {
+	if len(s.Resources) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.Resources {
+		if matchWildcard(pattern, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard reports whether the string matches the pattern,
+// where * matches any sequence of characters
+func matchWildcard(pattern, str string) bool {
+	parts := strings.Split(pattern, Wildcard)
+	if len(parts) == 1 {
+		return pattern == str
+	}
+
+	if !strings.HasPrefix(str, parts[0]) {
+		return false
+	}
+	str = str[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(str, part)
+		if i < 0 {
+			return false
+		}
+		str = str[i+len(part):]
+	}
+
+	return strings.HasSuffix(str, last)
+}
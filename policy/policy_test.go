@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDefs = permission.Definitions{
+	{Name: "doc", Subset: []string{"read", "edit", "delete"}, DefaultSubset: []string{"read"}},
+	{Name: "user", Subset: []string{"edit"}},
+}
+
+const yamlDoc = `
+id: editors
+statements:
+  - id: edit-docs
+    effect: allow
+    permissions: [doc.read, doc.edit]
+    resources: ["docs/*"]
+  - effect: deny
+    permissions: ["doc.*"]
+    resources: ["docs/secret/*"]
+    condition: "!user.admin"
+`
+
+const jsonDoc = `{
+  "id": "editors",
+  "statements": [
+    {"id": "edit-docs", "effect": "allow", "permissions": ["doc.read", "doc.edit"], "resources": ["docs/*"]},
+    {"effect": "deny", "permissions": ["doc.*"], "resources": ["docs/secret/*"], "condition": "!user.admin"}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	for _, data := range []string{yamlDoc, jsonDoc} {
+		doc, err := Parse([]byte(data))
+		require.NoError(t, err)
+
+		assert.Equal(t, "editors", doc.ID)
+		require.Len(t, doc.Statements, 2)
+		assert.Equal(t, "edit-docs", doc.Statements[0].ID)
+		assert.Equal(t, Allow, doc.Statements[0].Effect)
+		assert.Equal(t, []permission.Permission{{Name: "doc", Sub: "read"}, {Name: "doc", Sub: "edit"}}, doc.Statements[0].Permissions)
+		assert.Equal(t, []string{"docs/*"}, doc.Statements[0].Resources)
+		assert.Nil(t, doc.Statements[0].Condition)
+
+		assert.Equal(t, Deny, doc.Statements[1].Effect)
+		assert.Equal(t, []permission.Permission{{Name: "doc", Sub: "*"}}, doc.Statements[1].Permissions)
+		require.NotNil(t, doc.Statements[1].Condition)
+		assert.Equal(t, "!user.admin", doc.Statements[1].Condition.String())
+	}
+
+	_, err := Parse([]byte(`{"statements": [{"permissions": ["doc."]}]}`))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte("statements:\n  - condition: \"a &&\"\n"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	schema := permission.Schema{"user.admin": permission.BoolAttribute}
+
+	doc, err := Parse([]byte(yamlDoc))
+	require.NoError(t, err)
+	assert.NoError(t, doc.Validate(testDefs, schema))
+	assert.NoError(t, doc.Validate(testDefs, nil))
+
+	var condErr *permission.ConditionError
+	err = doc.Validate(testDefs, permission.Schema{})
+	require.True(t, errors.As(err, &condErr))
+	assert.Equal(t, 1, err.(*StatementError).Index)
+
+	tests := []struct {
+		statement Statement
+		err       error
+	}{
+		{Statement{Effect: Allow, Permissions: []permission.Permission{{Name: "*"}}}, nil},
+		{Statement{Effect: Allow, Permissions: []permission.Permission{{Name: "user", Sub: "*"}}}, nil},
+		{Statement{Effect: Allow, Permissions: []permission.Permission{{Name: "doc"}}}, nil},
+		{Statement{Effect: "permit", Permissions: []permission.Permission{{Name: "doc"}}}, ErrBadEffect},
+		{Statement{Effect: Deny}, ErrNoPermissions},
+		{Statement{Effect: Deny, Permissions: []permission.Permission{{Name: "doc", Sub: "share"}}}, ErrUnknownPermission},
+		{Statement{Effect: Deny, Permissions: []permission.Permission{{Name: "repo", Sub: "*"}}}, ErrUnknownPermission},
+		{Statement{Effect: Deny, Permissions: []permission.Permission{{Name: "*", Sub: "edit"}}}, permission.ErrBadFormat},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.err, test.statement.Validate(testDefs, nil))
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern, str string
+		expected     bool
+	}{
+		{"*", "", true},
+		{"*", "docs/1", true},
+		{"docs/1", "docs/1", true},
+		{"docs/1", "docs/12", false},
+		{"docs/*", "docs/1", true},
+		{"docs/*", "docs/", true},
+		{"docs/*", "doc", false},
+		{"*/1", "docs/1", true},
+		{"docs/*/files/*", "docs/a/files/b", true},
+		{"docs/*/files/*", "docs/a/b", false},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, matchWildcard(test.pattern, test.str), test.pattern+" "+test.str)
+	}
+}
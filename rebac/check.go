@@ -0,0 +1,287 @@
+package rebac
+
+import (
+	"context"
+	"sort"
+
+	"github.com/asdine/permission"
+)
+
+// DefaultMaxDepth is the default number of usersets a Checker follows in a chain
+const DefaultMaxDepth = 32
+
+// Checker evaluates relations using the rewrite rules of a Config
+// and the tuples of a TupleReader
+type Checker struct {
+	config Config
+	r      TupleReader
+
+	// MaxDepth is the maximum number of usersets followed in a chain.
+	// ErrMaxDepth is returned when it is reached
+	MaxDepth int
+}
+
+// NewChecker returns a Checker that uses the config and the tuples of r.
+// Returns an error if the config is not valid
+func NewChecker(config Config, r TupleReader) (*Checker, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checker{config: config, r: r, MaxDepth: DefaultMaxDepth}, nil
+}
+
+// Check reports whether the user has the relation with the object,
+// directly, through a userset or through the rewrite rules of the relation.
+// Returns ErrEmptyUser if the user is empty
+func (c *Checker) Check(ctx context.Context, object Object, relation, user string) (bool, error) {
+	if user == "" {
+		return false, ErrEmptyUser
+	}
+
+	ns := c.config.Namespace(object.Namespace)
+	if ns == nil {
+		return false, ErrUnknownNamespace
+	}
+
+	if !ns.HasRelation(relation) {
+		return false, ErrUnknownRelation
+	}
+
+	return c.check(ctx, Userset{Object: object, Relation: relation}, user, make(map[Userset]bool), 0)
+}
+
+// CheckPermission reports whether the user has the permission on the object.
+// The permission sub is the relation, a permission without sub is satisfied
+// by any relation of the namespace DefaultSubset.
+// Returns false if the permission doesn't belong to the namespace of the object
+// or is not listed in its Definition, and ErrEmptyUser if the user is empty
+func (c *Checker) CheckPermission(ctx context.Context, object Object, p permission.Permission, user string) (bool, error) {
+	if user == "" {
+		return false, ErrEmptyUser
+	}
+
+	ns := c.config.Namespace(object.Namespace)
+	if ns == nil {
+		return false, ErrUnknownNamespace
+	}
+
+	if p.Name != ns.Definition.Name {
+		return false, nil
+	}
+
+	relations := ns.Definition.DefaultSubset
+	if p.Sub != "" {
+		if !permission.InStringSlice(ns.Definition.Subset, p.Sub) {
+			return false, nil
+		}
+		relations = []string{p.Sub}
+	}
+
+	for _, relation := range relations {
+		ok, err := c.Check(ctx, object, relation, user)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// Require parses the required scope and reports whether the user has
+// any of its permissions on the object.
+// Returns ErrEmptyUser if the user is empty
+func (c *Checker) Require(ctx context.Context, object Object, required, user string) (bool, error) {
+	if user == "" {
+		return false, ErrEmptyUser
+	}
+
+	req, err := permission.ParseScope(required)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range req {
+		ok, err := c.CheckPermission(ctx, object, p, user)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (c *Checker) check(ctx context.Context, u Userset, user string, visited map[Userset]bool, depth int) (bool, error) {
+	if visited[u] {
+		return false, nil
+	}
+	visited[u] = true
+
+	if depth >= c.MaxDepth {
+		return false, ErrMaxDepth
+	}
+
+	ns := c.config.Namespace(u.Object.Namespace)
+	if ns == nil || !ns.HasRelation(u.Relation) {
+		return false, nil
+	}
+
+	subjects, err := c.r.Read(ctx, u.Object, u.Relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range subjects {
+		// userset subjects have no user
+		if s.User != "" {
+			if s.User == user {
+				return true, nil
+			}
+			continue
+		}
+
+		if s.Userset.Relation == "" {
+			continue
+		}
+
+		ok, err := c.check(ctx, s.Userset, user, visited, depth+1)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+
+	rewrite := ns.Relations[u.Relation]
+	for _, relation := range rewrite.Computed {
+		ok, err := c.check(ctx, Userset{Object: u.Object, Relation: relation}, user, visited, depth+1)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+
+	for _, ttu := range rewrite.TupleToUserset {
+		related, err := c.r.Read(ctx, u.Object, ttu.Tupleset)
+		if err != nil {
+			return false, err
+		}
+
+		for _, s := range related {
+			if s.User != "" {
+				continue
+			}
+
+			ok, err := c.check(ctx, Userset{Object: s.Userset.Object, Relation: ttu.Computed}, user, visited, depth+1)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Tree is the expansion of a Userset.
+// The subjects of the Userset are the Users and the subjects of the Children
+type Tree struct {
+	Userset  Userset
+	Users    []string
+	Children []*Tree
+}
+
+// Leaves returns the sorted users of the tree and of its children
+func (t *Tree) Leaves() []string {
+	seen := make(map[string]bool)
+	var users []string
+
+	var walk func(t *Tree)
+	walk = func(t *Tree) {
+		for _, user := range t.Users {
+			if !seen[user] {
+				seen[user] = true
+				users = append(users, user)
+			}
+		}
+		for _, child := range t.Children {
+			walk(child)
+		}
+	}
+	walk(t)
+
+	sort.Strings(users)
+	return users
+}
+
+// Expand returns the tree of the subjects that have the relation with the object.
+// A userset already expanded elsewhere in the tree appears as a leaf without users
+func (c *Checker) Expand(ctx context.Context, object Object, relation string) (*Tree, error) {
+	ns := c.config.Namespace(object.Namespace)
+	if ns == nil {
+		return nil, ErrUnknownNamespace
+	}
+
+	if !ns.HasRelation(relation) {
+		return nil, ErrUnknownRelation
+	}
+
+	return c.expand(ctx, Userset{Object: object, Relation: relation}, make(map[Userset]bool), 0)
+}
+
+func (c *Checker) expand(ctx context.Context, u Userset, visited map[Userset]bool, depth int) (*Tree, error) {
+	t := Tree{Userset: u}
+	if visited[u] {
+		return &t, nil
+	}
+	visited[u] = true
+
+	if depth >= c.MaxDepth {
+		return nil, ErrMaxDepth
+	}
+
+	ns := c.config.Namespace(u.Object.Namespace)
+	if ns == nil || !ns.HasRelation(u.Relation) {
+		return &t, nil
+	}
+
+	subjects, err := c.r.Read(ctx, u.Object, u.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []Userset
+	for _, s := range subjects {
+		switch {
+		case s.User != "":
+			t.Users = append(t.Users, s.User)
+		case s.Userset.Relation != "":
+			children = append(children, s.Userset)
+		}
+	}
+
+	rewrite := ns.Relations[u.Relation]
+	for _, relation := range rewrite.Computed {
+		children = append(children, Userset{Object: u.Object, Relation: relation})
+	}
+
+	for _, ttu := range rewrite.TupleToUserset {
+		related, err := c.r.Read(ctx, u.Object, ttu.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range related {
+			if s.User == "" {
+				children = append(children, Userset{Object: s.Userset.Object, Relation: ttu.Computed})
+			}
+		}
+	}
+
+	for _, child := range children {
+		ct, err := c.expand(ctx, child, visited, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		t.Children = append(t.Children, ct)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,217 @@
+package rebac
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = Config{
+	{
+		Definition: permission.Definition{
+			Name:          "doc",
+			Subset:        []string{"owner", "edit", "view"},
+			DefaultSubset: []string{"view"},
+		},
+		Relations: map[string]Rewrite{
+			"parent": {},
+			"viewer": {},
+			"edit":   {Computed: []string{"owner"}},
+			"view": {
+				Computed:       []string{"edit", "viewer"},
+				TupleToUserset: []TupleToUserset{{Tupleset: "parent", Computed: "view"}},
+			},
+		},
+	},
+	{
+		Definition: permission.Definition{
+			Name:   "folder",
+			Subset: []string{"view"},
+		},
+		Relations: map[string]Rewrite{
+			"parent": {},
+			"view":   {TupleToUserset: []TupleToUserset{{Tupleset: "parent", Computed: "view"}}},
+		},
+	},
+	{
+		Definition: permission.Definition{
+			Name:   "team",
+			Subset: []string{"member"},
+		},
+	},
+}
+
+func newTestChecker(t *testing.T, tuples ...string) *Checker {
+	s := NewMemoryStore()
+	for _, repr := range tuples {
+		tuple := mustTuple(t, repr)
+		require.NoError(t, testConfig.ValidateTuple(tuple), repr)
+		require.NoError(t, s.Write(context.Background(), tuple))
+	}
+
+	c, err := NewChecker(testConfig, s)
+	require.NoError(t, err)
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	c := newTestChecker(t,
+		"doc:7#owner@alice",
+		"doc:7#viewer@team:x#member",
+		"team:x#member@bob",
+		"doc:7#parent@folder:y",
+		"folder:y#parent@folder:root",
+		"folder:root#view@carol",
+		"doc:8#owner@dave",
+	)
+	doc := Object{Namespace: "doc", ID: "7"}
+
+	tests := []struct {
+		relation string
+		user     string
+		expected bool
+	}{
+		{"owner", "alice", true},
+		{"edit", "alice", true},
+		{"view", "alice", true},
+		{"edit", "bob", false},
+		{"view", "bob", true},
+		{"view", "carol", true},
+		{"edit", "carol", false},
+		{"view", "dave", false},
+		{"parent", "alice", false},
+	}
+
+	for _, test := range tests {
+		ok, err := c.Check(ctx, doc, test.relation, test.user)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, ok, fmt.Sprintf("%s %s", test.relation, test.user))
+	}
+
+	_, err := c.Check(ctx, Object{Namespace: "repo", ID: "1"}, "view", "alice")
+	assert.Equal(t, ErrUnknownNamespace, err)
+	_, err = c.Check(ctx, doc, "admin", "alice")
+	assert.Equal(t, ErrUnknownRelation, err)
+}
+
+func TestCheckPermission(t *testing.T) {
+	ctx := context.Background()
+	c := newTestChecker(t,
+		"doc:7#owner@alice",
+		"doc:7#viewer@bob",
+	)
+	doc := Object{Namespace: "doc", ID: "7"}
+
+	tests := []struct {
+		required string
+		user     string
+		expected bool
+	}{
+		{"doc.edit", "alice", true},
+		{"doc", "alice", true},
+		{"doc", "bob", true},
+		{"doc.edit", "bob", false},
+		{"doc.edit,doc.view", "bob", true},
+		// viewer is a relation but not a permission
+		{"doc.viewer", "bob", false},
+		{"folder.view", "alice", false},
+	}
+
+	for _, test := range tests {
+		ok, err := c.Require(ctx, doc, test.required, test.user)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, ok, fmt.Sprintf("%s %s", test.required, test.user))
+	}
+
+	_, err := c.Require(ctx, doc, "doc.", "alice")
+	assert.Equal(t, permission.ErrBadFormat, err)
+}
+
+func TestCheckCycle(t *testing.T) {
+	ctx := context.Background()
+	c := newTestChecker(t,
+		"team:a#member@team:b#member",
+		"team:b#member@team:a#member",
+		"team:b#member@alice",
+	)
+
+	ok, err := c.Check(ctx, Object{Namespace: "team", ID: "a"}, "member", "alice")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.Check(ctx, Object{Namespace: "team", ID: "a"}, "member", "bob")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCheckMaxDepth(t *testing.T) {
+	ctx := context.Background()
+	c := newTestChecker(t,
+		"team:a#member@team:b#member",
+		"team:b#member@team:c#member",
+		"team:c#member@alice",
+	)
+	c.MaxDepth = 2
+
+	_, err := c.Check(ctx, Object{Namespace: "team", ID: "a"}, "member", "alice")
+	assert.Equal(t, ErrMaxDepth, err)
+
+	c.MaxDepth = 3
+	ok, err := c.Check(ctx, Object{Namespace: "team", ID: "a"}, "member", "alice")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestExpand(t *testing.T) {
+	ctx := context.Background()
+	c := newTestChecker(t,
+		"doc:7#owner@alice",
+		"doc:7#viewer@team:x#member",
+		"team:x#member@bob",
+		"team:x#member@alice",
+		"doc:7#parent@folder:y",
+		"folder:y#view@carol",
+	)
+
+	tree, err := c.Expand(ctx, Object{Namespace: "doc", ID: "7"}, "view")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, tree.Leaves())
+	assert.Equal(t, "doc:7#view", tree.Userset.String())
+	require.Len(t, tree.Children, 3)
+	assert.Equal(t, "doc:7#edit", tree.Children[0].Userset.String())
+	assert.Equal(t, "doc:7#viewer", tree.Children[1].Userset.String())
+	assert.Equal(t, "folder:y#view", tree.Children[2].Userset.String())
+	assert.Equal(t, []string{"carol"}, tree.Children[2].Users)
+
+	tree, err = c.Expand(ctx, Object{Namespace: "doc", ID: "8"}, "view")
+	require.NoError(t, err)
+	assert.Len(t, tree.Leaves(), 0)
+
+	_, err = c.Expand(ctx, Object{Namespace: "doc", ID: "7"}, "admin")
+	assert.Equal(t, ErrUnknownRelation, err)
+}
+
+func TestCheckEmptyUser(t *testing.T) {
+	c := newTestChecker(t, "doc:7#viewer@team:x#member")
+	ctx := context.Background()
+	doc := Object{Namespace: "doc", ID: "7"}
+
+	_, err := c.Check(ctx, doc, "viewer", "")
+	assert.Equal(t, ErrEmptyUser, err)
+
+	_, err = c.CheckPermission(ctx, doc, permission.Permission{Name: "doc", Sub: "view"}, "")
+	assert.Equal(t, ErrEmptyUser, err)
+
+	_, err = c.Require(ctx, doc, "doc", "")
+	assert.Equal(t, ErrEmptyUser, err)
+
+	// userset subjects never match a user
+	ok, err := c.check(ctx, Userset{Object: doc, Relation: "viewer"}, "", make(map[Userset]bool), 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,107 @@
+package rebac
+
+import "github.com/asdine/permission"
+
+// TupleToUserset gives a relation to the subjects that have the Computed relation
+// with the objects related through the Tupleset relation.
+// For example {Tupleset: "parent", Computed: "viewer"} on doc#viewer
+// makes the viewers of the parent folder viewers of the doc
+type TupleToUserset struct {
+	Tupleset string
+	Computed string
+}
+
+// Rewrite lists the other ways a relation can be obtained, besides direct tuples
+type Rewrite struct {
+	// Computed lists the relations of the same object that imply the relation,
+	// e.g. owners are editors
+	Computed []string
+
+	TupleToUserset []TupleToUserset
+}
+
+// Namespace describes the relations of a type of objects
+type Namespace struct {
+	// Definition of the namespace.
+	// Its Name is the namespace name and its Subset the relations usable in permissions.
+	// Its DefaultSubset is used when a permission only specifies the namespace
+	Definition permission.Definition
+
+	// Relations maps relations to their rewrite rules.
+	// It can declare relations that are not permissions, like parent
+	Relations map[string]Rewrite
+}
+
+// HasRelation reports whether the relation is declared in the namespace
+func (n *Namespace) HasRelation(relation string) bool {
+	if _, ok := n.Relations[relation]; ok {
+		return true
+	}
+	return permission.InStringSlice(n.Definition.Subset, relation)
+}
+
+// Config is the set of namespaces
+type Config []Namespace
+
+// Namespace returns the Namespace with the given name
+func (c Config) Namespace(name string) *Namespace {
+	for i := range c {
+		if c[i].Definition.Name == name {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// Definitions returns the definitions of the namespaces
+func (c Config) Definitions() permission.Definitions {
+	defs := make(permission.Definitions, len(c))
+	for i := range c {
+		defs[i] = c[i].Definition
+	}
+	return defs
+}
+
+// Validate checks that every rewrite only uses declared relations
+func (c Config) Validate() error {
+	for i := range c {
+		ns := &c[i]
+		if ns.Definition.Name == "" {
+			return permission.ErrEmptyName
+		}
+
+		for _, rewrite := range ns.Relations {
+			for _, r := range rewrite.Computed {
+				if !ns.HasRelation(r) {
+					return ErrUnknownRelation
+				}
+			}
+
+			for _, ttu := range rewrite.TupleToUserset {
+				if !ns.HasRelation(ttu.Tupleset) || ttu.Computed == "" {
+					return ErrUnknownRelation
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateTuple checks that the tuple object and relation are declared in the config
+func (c Config) ValidateTuple(t Tuple) error {
+	ns := c.Namespace(t.Object.Namespace)
+	if ns == nil {
+		return ErrUnknownNamespace
+	}
+
+	if !ns.HasRelation(t.Relation) {
+		return ErrUnknownRelation
+	}
+
+	if t.Subject.User == "" && c.Namespace(t.Subject.Userset.Object.Namespace) == nil {
+		return ErrUnknownNamespace
+	}
+
+	return nil
+}
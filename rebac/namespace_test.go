@@ -0,0 +1,50 @@
+package rebac
+
+import (
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, testConfig.Validate())
+
+	assert.Equal(t, permission.ErrEmptyName, Config{{}}.Validate())
+
+	bad := Config{{
+		Definition: permission.Definition{Name: "doc", Subset: []string{"read"}},
+		Relations:  map[string]Rewrite{"read": {Computed: []string{"write"}}},
+	}}
+	assert.Equal(t, ErrUnknownRelation, bad.Validate())
+
+	bad = Config{{
+		Definition: permission.Definition{Name: "doc", Subset: []string{"read"}},
+		Relations:  map[string]Rewrite{"read": {TupleToUserset: []TupleToUserset{{Tupleset: "parent", Computed: "read"}}}},
+	}}
+	assert.Equal(t, ErrUnknownRelation, bad.Validate())
+}
+
+func TestConfigValidateTuple(t *testing.T) {
+	tests := map[string]error{
+		"doc:7#owner@alice":          nil,
+		"doc:7#parent@folder:y":      nil,
+		"doc:7#viewer@team:x#member": nil,
+		"doc:7#admin@alice":          ErrUnknownRelation,
+		"repo:7#owner@alice":         ErrUnknownNamespace,
+		"doc:7#owner@repo:x#member":  ErrUnknownNamespace,
+	}
+
+	for repr, expected := range tests {
+		tuple, err := ParseTuple(repr)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, testConfig.ValidateTuple(tuple), repr)
+	}
+}
+
+func TestConfigDefinitions(t *testing.T) {
+	defs := testConfig.Definitions()
+	assert.Len(t, defs, 3)
+	assert.True(t, defs.Require("doc.edit", "doc.edit"))
+	assert.True(t, defs.Require("doc", "doc.view"))
+}
@@ -0,0 +1,89 @@
+package rebac
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// TupleReader reads the subjects related to objects
+type TupleReader interface {
+	// Read returns the subjects that have the relation with the object, sorted
+	Read(ctx context.Context, object Object, relation string) ([]Subject, error)
+}
+
+// TupleStore stores relation tuples
+type TupleStore interface {
+	TupleReader
+
+	// Write adds the tuples to the store. Existing tuples are ignored
+	Write(ctx context.Context, tuples ...Tuple) error
+
+	// Delete removes the tuples from the store. Missing tuples are ignored
+	Delete(ctx context.Context, tuples ...Tuple) error
+}
+
+type objectRelation struct {
+	object   Object
+	relation string
+}
+
+// MemoryStore is a TupleStore that keeps the tuples in memory.
+// It is safe for concurrent use
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tuples map[objectRelation]map[Subject]struct{}
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tuples: make(map[objectRelation]map[Subject]struct{}),
+	}
+}
+
+// Read returns the subjects that have the relation with the object, sorted
+func (m *MemoryStore) Read(ctx context.Context, object Object, relation string) ([]Subject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subjects := make([]Subject, 0, len(m.tuples[objectRelation{object, relation}]))
+	for s := range m.tuples[objectRelation{object, relation}] {
+		subjects = append(subjects, s)
+	}
+
+	sort.Slice(subjects, func(i, j int) bool {
+		return subjects[i].String() < subjects[j].String()
+	})
+	return subjects, nil
+}
+
+// Write adds the tuples to the store
+func (m *MemoryStore) Write(ctx context.Context, tuples ...Tuple) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range tuples {
+		key := objectRelation{t.Object, t.Relation}
+		if m.tuples[key] == nil {
+			m.tuples[key] = make(map[Subject]struct{})
+		}
+		m.tuples[key][t.Subject] = struct{}{}
+	}
+	return nil
+}
+
+// Delete removes the tuples from the store
+func (m *MemoryStore) Delete(ctx context.Context, tuples ...Tuple) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range tuples {
+		key := objectRelation{t.Object, t.Relation}
+		delete(m.tuples[key], t.Subject)
+		if len(m.tuples[key]) == 0 {
+			delete(m.tuples, key)
+		}
+	}
+	return nil
+}
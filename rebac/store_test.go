@@ -0,0 +1,44 @@
+package rebac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	doc := Object{Namespace: "doc", ID: "7"}
+
+	require.NoError(t, s.Write(ctx,
+		mustTuple(t, "doc:7#viewer@team:x#member"),
+		mustTuple(t, "doc:7#viewer@bob"),
+		mustTuple(t, "doc:7#viewer@alice"),
+		mustTuple(t, "doc:7#viewer@alice"),
+		mustTuple(t, "doc:7#owner@carol"),
+	))
+
+	subjects, err := s.Read(ctx, doc, "viewer")
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{{User: "alice"}, {User: "bob"}, mustTuple(t, "doc:7#viewer@team:x#member").Subject}, subjects)
+
+	require.NoError(t, s.Delete(ctx, mustTuple(t, "doc:7#viewer@bob"), mustTuple(t, "doc:7#viewer@nobody")))
+	subjects, err = s.Read(ctx, doc, "viewer")
+	require.NoError(t, err)
+	assert.Len(t, subjects, 2)
+
+	require.NoError(t, s.Delete(ctx, mustTuple(t, "doc:7#owner@carol")))
+	subjects, err = s.Read(ctx, doc, "owner")
+	require.NoError(t, err)
+	assert.Len(t, subjects, 0)
+	assert.Len(t, s.tuples, 1)
+}
+
+func mustTuple(t *testing.T, repr string) Tuple {
+	tuple, err := ParseTuple(repr)
+	require.NoError(t, err)
+	return tuple
+}
@@ -0,0 +1,140 @@
+// Package rebac provides relationship-based authorization on top of permission Definitions.
+//
+// Relations between objects and subjects are stored as tuples, e.g.
+//
+//	doc:7#owner@alice             alice is an owner of doc 7
+//	folder:y#viewer@team:x#member members of team x are viewers of folder y
+//	doc:7#parent@folder:y         folder y is the parent of doc 7
+//
+// Each namespace is described by a permission.Definition whose Subset lists its relations,
+// so that existing permission names like doc.edit keep working for instance-level checks.
+package rebac
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/asdine/permission"
+)
+
+// Errors
+var (
+	ErrUnknownNamespace = errors.New("The namespace is not defined")
+	ErrUnknownRelation  = errors.New("The relation is not defined in the namespace")
+	ErrMaxDepth         = errors.New("The maximum check depth was reached")
+	ErrEmptyUser        = errors.New("The user is empty")
+)
+
+// Object is an instance of a namespace, e.g. doc:7
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+// ParseObject parses the namespace:id representation of an object
+func ParseObject(repr string) (Object, error) {
+	i := strings.IndexByte(repr, ':')
+	if i <= 0 || i == len(repr)-1 || strings.ContainsAny(repr, "#@") {
+		return Object{}, permission.ErrBadFormat
+	}
+
+	return Object{Namespace: repr[:i], ID: repr[i+1:]}, nil
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Userset is the set of subjects that have a relation with an object, e.g. team:x#member.
+// A Userset with an empty relation refers to the object itself, e.g. the parent folder:y
+type Userset struct {
+	Object   Object
+	Relation string
+}
+
+func (u Userset) String() string {
+	if u.Relation == "" {
+		return u.Object.String()
+	}
+	return u.Object.String() + "#" + u.Relation
+}
+
+// Subject is either a user or a Userset
+type Subject struct {
+	// User id, empty if the subject is a Userset
+	User string
+
+	Userset Userset
+}
+
+// ParseSubject parses a user id, an object or an object#relation userset
+func ParseSubject(repr string) (Subject, error) {
+	if repr == "" || strings.IndexByte(repr, '@') >= 0 {
+		return Subject{}, permission.ErrBadFormat
+	}
+
+	if strings.IndexByte(repr, ':') < 0 {
+		if strings.IndexByte(repr, '#') >= 0 {
+			return Subject{}, permission.ErrBadFormat
+		}
+		return Subject{User: repr}, nil
+	}
+
+	var relation string
+	if i := strings.IndexByte(repr, '#'); i >= 0 {
+		repr, relation = repr[:i], repr[i+1:]
+		if relation == "" {
+			return Subject{}, permission.ErrBadFormat
+		}
+	}
+
+	o, err := ParseObject(repr)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	return Subject{Userset: Userset{Object: o, Relation: relation}}, nil
+}
+
+func (s Subject) String() string {
+	if s.User != "" {
+		return s.User
+	}
+	return s.Userset.String()
+}
+
+// Tuple states that the subject has the relation with the object
+type Tuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+// ParseTuple parses the object#relation@subject representation of a tuple
+func ParseTuple(repr string) (Tuple, error) {
+	at := strings.IndexByte(repr, '@')
+	if at < 0 {
+		return Tuple{}, permission.ErrBadFormat
+	}
+
+	hash := strings.IndexByte(repr[:at], '#')
+	if hash < 0 || hash == at-1 {
+		return Tuple{}, permission.ErrBadFormat
+	}
+
+	o, err := ParseObject(repr[:hash])
+	if err != nil {
+		return Tuple{}, err
+	}
+
+	s, err := ParseSubject(repr[at+1:])
+	if err != nil {
+		return Tuple{}, err
+	}
+
+	return Tuple{Object: o, Relation: repr[hash+1 : at], Subject: s}, nil
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
@@ -0,0 +1,58 @@
+package rebac
+
+import (
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTuple(t *testing.T) {
+	tests := []struct {
+		repr     string
+		expected Tuple
+	}{
+		{"doc:7#owner@alice", Tuple{
+			Object:   Object{Namespace: "doc", ID: "7"},
+			Relation: "owner",
+			Subject:  Subject{User: "alice"},
+		}},
+		{"folder:y#viewer@team:x#member", Tuple{
+			Object:   Object{Namespace: "folder", ID: "y"},
+			Relation: "viewer",
+			Subject:  Subject{Userset: Userset{Object: Object{Namespace: "team", ID: "x"}, Relation: "member"}},
+		}},
+		{"doc:7#parent@folder:y", Tuple{
+			Object:   Object{Namespace: "doc", ID: "7"},
+			Relation: "parent",
+			Subject:  Subject{Userset: Userset{Object: Object{Namespace: "folder", ID: "y"}}},
+		}},
+	}
+
+	for _, test := range tests {
+		tuple, err := ParseTuple(test.repr)
+		require.NoError(t, err, test.repr)
+		assert.Equal(t, test.expected, tuple)
+		assert.Equal(t, test.repr, tuple.String())
+	}
+
+	bad := []string{
+		"",
+		"doc:7#owner",
+		"doc:7@alice",
+		"doc:7#@alice",
+		"doc#owner@alice",
+		":7#owner@alice",
+		"doc:#owner@alice",
+		"doc:7#owner@",
+		"doc:7#owner@al#ice",
+		"doc:7#owner@team:x#",
+		"doc:7#owner@team:x@y",
+	}
+
+	for _, repr := range bad {
+		_, err := ParseTuple(repr)
+		assert.Equal(t, permission.ErrBadFormat, err, repr)
+	}
+}
@@ -0,0 +1,55 @@
+package permission
+
+import "context"
+
+// Role is a named Scope that can be assigned to subjects.
+type Role struct {
+	// Name of the role
+	Name string
+
+	// Scope granted to the subjects the role is assigned to
+	Scope Scope
+}
+
+// Roles are a group of Role
+type Roles []Role
+
+// Role returns the Role with the given name
+func (r Roles) Role(name string) *Role {
+	for i := range r {
+		if r[i].Name == name {
+			return &r[i]
+		}
+	}
+	return nil
+}
+
+// Scope returns the union of the scopes of the given roles.
+// Unknown roles are ignored
+func (r Roles) Scope(names ...string) Scope {
+	var s Scope
+	for _, name := range names {
+		role := r.Role(name)
+		if role != nil {
+			s = union(s, role.Scope)
+		}
+	}
+	return s
+}
+
+// EffectiveScope returns the permissions granted to the subject, directly or through its roles
+func EffectiveScope(ctx context.Context, r StoreReader, roles Roles, subject string) (Scope, error) {
+	s, err := r.ScopeOf(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned, err := r.RolesOf(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	s = union(s, roles.Scope(assigned...))
+	SortScope(s)
+	return s, nil
+}
@@ -0,0 +1,61 @@
+package permission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles_Role(t *testing.T) {
+	r := Roles{
+		{Name: "admin", Scope: Scope{{Name: "user"}}},
+		{Name: "editor", Scope: Scope{{Name: "playlist", Sub: "edit"}}},
+	}
+
+	assert.Equal(t, &r[0], r.Role("admin"))
+	assert.Equal(t, &r[1], r.Role("editor"))
+	assert.Nil(t, r.Role("viewer"))
+	assert.Nil(t, r.Role(""))
+}
+
+func TestRoles_Scope(t *testing.T) {
+	r := Roles{
+		{Name: "admin", Scope: Scope{{Name: "user"}, {Name: "playlist", Sub: "edit"}}},
+		{Name: "editor", Scope: Scope{{Name: "playlist", Sub: "edit"}, {Name: "playlist", Sub: "read"}}},
+	}
+
+	assert.Nil(t, r.Scope())
+	assert.Nil(t, r.Scope("viewer"))
+	assert.Equal(t, r[0].Scope, r.Scope("admin"))
+	assert.Equal(t, Scope{{Name: "user"}, {Name: "playlist", Sub: "edit"}, {Name: "playlist", Sub: "read"}}, r.Scope("admin", "viewer", "editor"))
+}
+
+func TestEffectiveScope(t *testing.T) {
+	ctx := context.Background()
+	r := Roles{
+		{Name: "admin", Scope: Scope{{Name: "user"}, {Name: "playlist", Sub: "edit"}}},
+		{Name: "editor", Scope: Scope{{Name: "playlist", Sub: "edit"}}},
+	}
+
+	store := NewMemoryStore()
+	require.NoError(t, store.Grant(ctx, "alice", Scope{{Name: "playlist", Sub: "edit"}, {Name: "friends"}}))
+	require.NoError(t, store.Assign(ctx, "alice", "admin", "editor", "unknown"))
+	require.NoError(t, store.Assign(ctx, "bob", "editor"))
+
+	s, err := EffectiveScope(ctx, store, r, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "friends"}, {Name: "playlist", Sub: "edit"}, {Name: "user"}}, s)
+
+	s, err = EffectiveScope(ctx, store, r, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "playlist", Sub: "edit"}}, s)
+
+	s, err = EffectiveScope(ctx, store, r, "carol")
+	require.NoError(t, err)
+	assert.Len(t, s, 0)
+
+	_, err = EffectiveScope(ctx, store, r, "")
+	assert.Equal(t, ErrEmptySubject, err)
+}
@@ -1,7 +1,6 @@
 package permission
 
 import (
-	"bytes"
 	"strings"
 	"sync"
 )
@@ -18,53 +17,106 @@ func Separator(sep string) {
 	separator = sep
 }
 
-// ParseScope takes a string representation and returns the corresponding Scope
+// ParseScope takes a string representation and returns the corresponding Scope.
+// The permissions share the memory of repr, only the Scope is allocated
 func ParseScope(repr string) (Scope, error) {
-	s := Scope{}
-	err := s.UnmarshalText([]byte(repr))
+	if len(repr) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	n := 1
+	if separator != "" {
+		n += strings.Count(repr, separator)
+	}
+
+	s, err := ParseScopeInto(make(Scope, 0, n), repr)
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
+// ParseScopeInto parses repr like ParseScope and appends the permissions to dst[:0].
+// It doesn't allocate if dst has enough capacity, which allows to reuse a Scope between calls
+func ParseScopeInto(dst Scope, repr string) (Scope, error) {
+	dst = dst[:0]
+	if len(repr) == 0 {
+		return dst, ErrEmptyInput
+	}
+
+	for {
+		i := -1
+		if separator != "" {
+			i = strings.Index(repr, separator)
+		}
+
+		elem := repr
+		if i >= 0 {
+			elem = repr[:i]
+		}
+
+		p, err := Parse(elem)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, p)
+
+		if i < 0 {
+			return dst, nil
+		}
+		repr = repr[i+len(separator):]
+	}
+}
+
 // Scope is a set of Permissions.
 // It can safely be converted back and forth to json
 type Scope []Permission
 
 // MarshalText implements the encoding.TextMarshaler interface
 func (s Scope) MarshalText() (text []byte, err error) {
-	var buffer bytes.Buffer
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	n := 0
+	for _, perm := range s {
+		n += len(perm.Name) + len(delimiter) + len(perm.Sub) + len(separator)
+	}
+
+	text, err = s.AppendText(make([]byte, 0, n))
+	if err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+// AppendText appends the text representation of the scope to b and returns the extended buffer.
+// It implements the encoding.TextAppender interface and doesn't allocate if b is large enough
+func (s Scope) AppendText(b []byte) ([]byte, error) {
+	var err error
 
 	for i, perm := range s {
-		raw, err := perm.MarshalText()
-		if err != nil {
-			return nil, err
+		if i > 0 {
+			b = append(b, separator...)
 		}
 
-		buffer.Write(raw)
-		if i < len(s)-1 {
-			buffer.WriteString(separator)
+		b, err = perm.AppendText(b)
+		if err != nil {
+			return b, err
 		}
 	}
 
-	return buffer.Bytes(), nil
+	return b, nil
 }
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface
 func (s *Scope) UnmarshalText(text []byte) error {
-	if len(text) == 0 {
-		return ErrEmptyInput
+	scope, err := ParseScope(string(text))
+	if err != nil {
+		return err
 	}
 
-	scope := strings.Split(string(text), separator)
-	*s = make([]Permission, len(scope))
-	for i, perm := range scope {
-		err := (*s)[i].UnmarshalText([]byte(perm))
-		if err != nil {
-			return err
-		}
-	}
+	*s = scope
 	return nil
 }
 
@@ -88,3 +140,50 @@ func (s *Scope) Has(repr string) bool {
 
 	return s.HasPermission(p)
 }
+
+// Normalize returns a sorted copy of the scope without duplicates
+func (s Scope) Normalize() Scope {
+	n := make(Scope, 0, len(s))
+	n = union(n, s)
+	SortScope(n)
+	return n
+}
+
+// Union returns the permissions of s followed by the permissions of t that are not in s
+func (s Scope) Union(t Scope) Scope {
+	u := make(Scope, 0, len(s)+len(t))
+	u = union(u, s)
+	return union(u, t)
+}
+
+// Intersect returns the permissions of s that are also in t
+func (s Scope) Intersect(t Scope) Scope {
+	i := Scope{}
+	for _, p := range s {
+		if t.HasPermission(p) && !i.HasPermission(p) {
+			i = append(i, p)
+		}
+	}
+	return i
+}
+
+// Difference returns the permissions of s that are not in t
+func (s Scope) Difference(t Scope) Scope {
+	d := Scope{}
+	for _, p := range s {
+		if !t.HasPermission(p) && !d.HasPermission(p) {
+			d = append(d, p)
+		}
+	}
+	return d
+}
+
+// union appends to s the permissions of t it doesn't have
+func union(s, t Scope) Scope {
+	for _, p := range t {
+		if !s.HasPermission(p) {
+			s = append(s, p)
+		}
+	}
+	return s
+}
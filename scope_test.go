@@ -176,3 +176,121 @@ func TestScopeHas(t *testing.T) {
 	assert.False(t, s.Has("c"))
 	assert.False(t, s.Has("c.i"))
 }
+
+func TestScopeNormalize(t *testing.T) {
+	s := Scope{{Name: "b"}, {Name: "a", Sub: "z"}, {Name: "b"}, {Name: "a"}}
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "a", Sub: "z"}, {Name: "b"}}, s.Normalize())
+	assert.Equal(t, Permission{Name: "b"}, s[0])
+	assert.Equal(t, Scope{}, Scope(nil).Normalize())
+}
+
+func TestScopeSetOperations(t *testing.T) {
+	s := Scope{{Name: "a"}, {Name: "b", Sub: "x"}, {Name: "a"}, {Name: "c"}}
+	u := Scope{{Name: "c"}, {Name: "b"}, {Name: "b", Sub: "x"}}
+
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "b", Sub: "x"}, {Name: "c"}, {Name: "b"}}, s.Union(u))
+	assert.Equal(t, Scope{{Name: "b", Sub: "x"}, {Name: "c"}}, s.Intersect(u))
+	assert.Equal(t, Scope{{Name: "a"}}, s.Difference(u))
+	assert.Equal(t, Scope{{Name: "b"}}, u.Difference(s))
+
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "b", Sub: "x"}, {Name: "c"}}, s.Union(nil))
+	assert.Equal(t, Scope{}, s.Intersect(nil))
+	assert.Equal(t, Scope{}, Scope(nil).Difference(u))
+	assert.Len(t, s, 4)
+}
+
+func TestParseScopeInto(t *testing.T) {
+	s, err := ParseScopeInto(nil, "a,b.i")
+	assert.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "b", Sub: "i"}}, s)
+
+	r, err := ParseScopeInto(s, "c")
+	assert.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "c"}}, r)
+	assert.Equal(t, &s[0], &r[0])
+
+	_, err = ParseScopeInto(s, "")
+	assert.Equal(t, ErrEmptyInput, err)
+
+	_, err = ParseScopeInto(s, "a,,b")
+	assert.Equal(t, ErrEmptyInput, err)
+
+	_, err = ParseScopeInto(s, "a,b.")
+	assert.Equal(t, ErrBadFormat, err)
+
+	Separator(" ")
+	defer Separator(",")
+	s, err = ParseScopeInto(s, "a b.i")
+	assert.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "b", Sub: "i"}}, s)
+}
+
+func TestScopeAppendText(t *testing.T) {
+	b, err := Scope{{Name: "a"}, {Name: "b", Sub: "i"}}.AppendText([]byte("scope="))
+	assert.NoError(t, err)
+	assert.Equal(t, "scope=a,b.i", string(b))
+
+	b, err = Scope{}.AppendText(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestScopeAllocs(t *testing.T) {
+	const repr = "user.edit,user.profile,playlist,playlist.read"
+
+	var s Scope
+	var err error
+	buf := make([]byte, 0, 64)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		s, err = ParseScope(repr)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		s, err = ParseScopeInto(s, repr)
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, allocs)
+	assert.Len(t, s, 4)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		buf, err = s.AppendText(buf[:0])
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, allocs)
+	assert.Equal(t, repr, string(buf))
+
+	allocs = testing.AllocsPerRun(100, func() {
+		_, err = s.MarshalText()
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), allocs)
+}
+
+func BenchmarkParseScope(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseScope("user.edit,user.profile,playlist,playlist.read")
+	}
+}
+
+func BenchmarkParseScopeInto(b *testing.B) {
+	var s Scope
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s, _ = ParseScopeInto(s, "user.edit,user.profile,playlist,playlist.read")
+	}
+}
+
+func BenchmarkScopeAppendText(b *testing.B) {
+	s := Scope{{Name: "user", Sub: "edit"}, {Name: "user", Sub: "profile"}, {Name: "playlist"}}
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = s.AppendText(buf[:0])
+	}
+}
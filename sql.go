@@ -0,0 +1,217 @@
+package permission
+
+import (
+	"bytes"
+	"database/sql/driver"
+)
+
+// Scan implements the sql.Scanner interface.
+// NULL and empty strings are scanned as a zero Permission
+func (p *Permission) Scan(src interface{}) error {
+	text, err := scanText(src)
+	if err != nil {
+		return err
+	}
+
+	*p = Permission{}
+	if len(text) == 0 {
+		return nil
+	}
+
+	return p.UnmarshalText(text)
+}
+
+// Value implements the driver.Valuer interface.
+// A zero Permission is stored as NULL
+func (p Permission) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+
+	text, err := p.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// Scan implements the sql.Scanner interface.
+// NULL is scanned as a nil Scope and an empty string as an empty Scope
+func (s *Scope) Scan(src interface{}) error {
+	if src == nil {
+		*s = nil
+		return nil
+	}
+
+	text, err := scanText(src)
+	if err != nil {
+		return err
+	}
+
+	if len(text) == 0 {
+		*s = Scope{}
+		return nil
+	}
+
+	return s.UnmarshalText(text)
+}
+
+// Value implements the driver.Valuer interface.
+// A nil Scope is stored as NULL and an empty Scope as an empty string
+func (s Scope) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	text, err := s.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// ScopeArray is a Scope stored as an SQL array of permissions, like a Postgres text[] column.
+// It uses the array literal format, e.g. {user.edit,profile}, and ignores the global separator
+type ScopeArray Scope
+
+// Scan implements the sql.Scanner interface.
+// NULL is scanned as a nil ScopeArray
+func (a *ScopeArray) Scan(src interface{}) error {
+	if src == nil {
+		*a = nil
+		return nil
+	}
+
+	text, err := scanText(src)
+	if err != nil {
+		return err
+	}
+
+	elems, err := parseArray(text)
+	if err != nil {
+		return err
+	}
+
+	s := make(ScopeArray, len(elems))
+	for i, elem := range elems {
+		err = s[i].UnmarshalText(elem)
+		if err != nil {
+			return err
+		}
+	}
+
+	*a = s
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+// A nil ScopeArray is stored as NULL
+func (a ScopeArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, perm := range a {
+		raw, err := perm.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		writeArrayElem(&buffer, raw)
+	}
+	buffer.WriteByte('}')
+
+	return buffer.String(), nil
+}
+
+func scanText(src interface{}) ([]byte, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+
+	return nil, ErrUnsupportedType
+}
+
+// parseArray splits a one-dimensional array literal into its elements
+func parseArray(text []byte) ([][]byte, error) {
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, ErrBadFormat
+	}
+
+	text = text[1 : len(text)-1]
+	if len(text) == 0 {
+		return [][]byte{}, nil
+	}
+
+	var elems [][]byte
+	for {
+		var elem []byte
+		if text[0] == '"' {
+			i := 1
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+					if i == len(text) {
+						return nil, ErrBadFormat
+					}
+				}
+				elem = append(elem, text[i])
+			}
+			if i == len(text) {
+				return nil, ErrBadFormat
+			}
+			text = text[i+1:]
+		} else {
+			i := bytes.IndexByte(text, ',')
+			if i < 0 {
+				i = len(text)
+			}
+			elem = text[:i]
+			text = text[i:]
+			if bytes.EqualFold(elem, []byte("NULL")) {
+				return nil, ErrNullElement
+			}
+			if len(elem) == 0 || bytes.ContainsAny(elem, `{}"\`) {
+				return nil, ErrBadFormat
+			}
+		}
+
+		elems = append(elems, elem)
+		if len(text) == 0 {
+			return elems, nil
+		}
+		if text[0] != ',' || len(text) == 1 {
+			return nil, ErrBadFormat
+		}
+		text = text[1:]
+	}
+}
+
+// writeArrayElem writes an array element, quoting it when required
+func writeArrayElem(buffer *bytes.Buffer, elem []byte) {
+	if !bytes.ContainsAny(elem, "{},\"\\ \t\n\r\v\f") && !bytes.EqualFold(elem, []byte("NULL")) {
+		buffer.Write(elem)
+		return
+	}
+
+	buffer.WriteByte('"')
+	for _, c := range elem {
+		if c == '"' || c == '\\' {
+			buffer.WriteByte('\\')
+		}
+		buffer.WriteByte(c)
+	}
+	buffer.WriteByte('"')
+}
@@ -0,0 +1,224 @@
+package permission
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestPermissionScan(t *testing.T) {
+	p := Permission{}
+
+	err := p.Scan("a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, Permission{Name: "a", Sub: "b"}, p)
+
+	err = p.Scan([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, Permission{Name: "a"}, p)
+
+	err = p.Scan(nil)
+	assert.NoError(t, err)
+	assert.True(t, p.IsZero())
+
+	p = Permission{Name: "a"}
+	err = p.Scan("")
+	assert.NoError(t, err)
+	assert.True(t, p.IsZero())
+
+	err = p.Scan("a.")
+	assert.Equal(t, ErrBadFormat, err)
+
+	err = p.Scan(10)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestPermissionValue(t *testing.T) {
+	v, err := Permission{Name: "a", Sub: "b"}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "a.b", v)
+
+	v, err = Permission{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = Permission{Sub: "b"}.Value()
+	assert.Equal(t, ErrEmptyName, err)
+}
+
+func TestScopeScan(t *testing.T) {
+	var s Scope
+
+	err := s.Scan("a,b.i")
+	assert.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "a"}, {Name: "b", Sub: "i"}}, s)
+
+	err = s.Scan([]byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, Scope{{Name: "c"}}, s)
+
+	err = s.Scan("")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	assert.Len(t, s, 0)
+
+	err = s.Scan(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	err = s.Scan("a,")
+	assert.Error(t, err)
+
+	err = s.Scan(true)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestScopeValue(t *testing.T) {
+	v, err := Scope{{Name: "a"}, {Name: "b", Sub: "i"}}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b.i", v)
+
+	v, err = Scope{}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	v, err = Scope(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = Scope{{}}.Value()
+	assert.Equal(t, ErrEmptyName, err)
+}
+
+func TestScopeArrayScan(t *testing.T) {
+	var a ScopeArray
+
+	err := a.Scan("{a,b.i}")
+	assert.NoError(t, err)
+	assert.Equal(t, ScopeArray{{Name: "a"}, {Name: "b", Sub: "i"}}, a)
+
+	err = a.Scan([]byte(`{"a b","c\"d.\\e",f}`))
+	assert.NoError(t, err)
+	assert.Equal(t, ScopeArray{{Name: "a b"}, {Name: `c"d`, Sub: `\e`}, {Name: "f"}}, a)
+
+	err = a.Scan("{}")
+	assert.NoError(t, err)
+	assert.NotNil(t, a)
+	assert.Len(t, a, 0)
+
+	err = a.Scan(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+
+	err = a.Scan("{a,NULL}")
+	assert.Equal(t, ErrNullElement, err)
+
+	for _, input := range []string{"", "a,b", "{a,}", "{,a}", `{"a}`, `{"a"b}`, "{{a}}", "{a.}"} {
+		err = a.Scan(input)
+		assert.Equal(t, ErrBadFormat, err, input)
+	}
+
+	err = a.Scan(1.5)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
+
+func TestScopeArrayValue(t *testing.T) {
+	v, err := ScopeArray{{Name: "a"}, {Name: "b", Sub: "i"}}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "{a,b.i}", v)
+
+	v, err = ScopeArray{{Name: "a b"}, {Name: `c"d`, Sub: `\e`}, {Name: "null"}}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a b","c\"d.\\e","null"}`, v)
+
+	v, err = ScopeArray{}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", v)
+
+	v, err = ScopeArray(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = ScopeArray{{}}.Value()
+	assert.Equal(t, ErrEmptyName, err)
+}
+
+func TestScopeArrayRoundTrip(t *testing.T) {
+	Separator(" ")
+	defer Separator(",")
+
+	s := ScopeArray{{Name: "a"}, {Name: "b,c", Sub: "i"}, {Name: "{d}"}}
+
+	v, err := s.Value()
+	assert.NoError(t, err)
+
+	var a ScopeArray
+	err = a.Scan(v)
+	assert.NoError(t, err)
+	assert.Equal(t, s, a)
+}
+
+func TestSQLRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// each connection to :memory: opens a distinct database
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE grants (id INTEGER PRIMARY KEY, perm TEXT, scope TEXT, arr TEXT)")
+	require.NoError(t, err)
+
+	rows := []struct {
+		perm  Permission
+		scope Scope
+		arr   ScopeArray
+	}{
+		{Permission{Name: "a", Sub: "b"}, Scope{{Name: "a"}, {Name: "b", Sub: "i"}}, ScopeArray{{Name: "a b"}, {Name: "c"}}},
+		{Permission{Name: "a"}, Scope{}, ScopeArray{}},
+		{Permission{}, nil, nil},
+	}
+
+	for i, r := range rows {
+		_, err = db.Exec("INSERT INTO grants (id, perm, scope, arr) VALUES (?, ?, ?, ?)", i, r.perm, r.scope, r.arr)
+		require.NoError(t, err)
+	}
+
+	for i, r := range rows {
+		var (
+			p Permission
+			s Scope
+			a ScopeArray
+		)
+		err = db.QueryRow("SELECT perm, scope, arr FROM grants WHERE id = ?", i).Scan(&p, &s, &a)
+		require.NoError(t, err)
+		assert.Equal(t, r.perm, p, i)
+		assert.Equal(t, r.scope, s, i)
+		assert.Equal(t, r.arr, a, i)
+	}
+
+	// zero permissions and nil scopes are stored as NULL
+	var nulls int
+	err = db.QueryRow("SELECT count(*) FROM grants WHERE perm IS NULL AND scope IS NULL AND arr IS NULL").Scan(&nulls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nulls)
+
+	// empty scopes are not
+	err = db.QueryRow("SELECT count(*) FROM grants WHERE scope = '' AND arr = '{}'").Scan(&nulls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nulls)
+
+	// invalid values are rejected before reaching the database
+	_, err = db.Exec("INSERT INTO grants (id, perm) VALUES (?, ?)", 3, Permission{Sub: "b"})
+	assert.Error(t, err)
+
+	_, err = db.Exec("INSERT INTO grants (id, perm) VALUES (?, ?)", 3, "a.")
+	require.NoError(t, err)
+	var p Permission
+	err = db.QueryRow("SELECT perm FROM grants WHERE id = ?", 3).Scan(&p)
+	assert.True(t, errors.Is(err, ErrBadFormat))
+}
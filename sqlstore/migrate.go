@@ -0,0 +1,78 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+)
+
+// migrations are applied in order, each one exactly once.
+// Never edit a released migration, append a new one instead
+var migrations = []string{
+	`CREATE TABLE permission_grants (
+		subject TEXT NOT NULL,
+		name TEXT NOT NULL,
+		sub TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (subject, name, sub)
+	)`,
+	`CREATE INDEX permission_grants_permission ON permission_grants (name, sub, subject)`,
+	`CREATE TABLE permission_roles (
+		subject TEXT NOT NULL,
+		role TEXT NOT NULL,
+		PRIMARY KEY (subject, role)
+	)`,
+	`CREATE INDEX permission_roles_role ON permission_roles (role, subject)`,
+}
+
+// Migrate creates or upgrades the tables used by the Store.
+// It is safe to call Migrate on an up-to-date database
+func (s *Store) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS permission_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err = s.migrate(ctx, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Version returns the number of migrations applied to the database
+func (s *Store) Version(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(version) FROM permission_migrations`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(version.Int64), nil
+}
+
+func (s *Store) migrate(ctx context.Context, i int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, migrations[i])
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO permission_migrations (version) VALUES (?)`), i+1)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,340 @@
+// Package sqlstore provides a permission.Store backed by a database/sql database.
+// SQLite and PostgreSQL are supported
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asdine/permission"
+)
+
+// Dialect describes the SQL flavour of the database
+type Dialect int
+
+// Supported dialects
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// New returns a Store using the given database.
+// Migrate must be called before using the Store
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// Store is a permission.Store backed by an SQL database
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Grant implements the permission.StoreTx interface
+func (s *Store) Grant(ctx context.Context, subject string, scope permission.Scope) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Grant(ctx, subject, scope)
+	})
+}
+
+// Revoke implements the permission.StoreTx interface
+func (s *Store) Revoke(ctx context.Context, subject string, scope permission.Scope) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Revoke(ctx, subject, scope)
+	})
+}
+
+// Assign implements the permission.StoreTx interface
+func (s *Store) Assign(ctx context.Context, subject string, roles ...string) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Assign(ctx, subject, roles...)
+	})
+}
+
+// Unassign implements the permission.StoreTx interface
+func (s *Store) Unassign(ctx context.Context, subject string, roles ...string) error {
+	return s.Update(ctx, func(tx permission.StoreTx) error {
+		return tx.Unassign(ctx, subject, roles...)
+	})
+}
+
+// ScopeOf implements the permission.StoreReader interface
+func (s *Store) ScopeOf(ctx context.Context, subject string) (permission.Scope, error) {
+	return s.tx(s.db).ScopeOf(ctx, subject)
+}
+
+// SubjectsWith implements the permission.StoreReader interface
+func (s *Store) SubjectsWith(ctx context.Context, p permission.Permission) ([]string, error) {
+	return s.tx(s.db).SubjectsWith(ctx, p)
+}
+
+// SubjectsWithPrefix implements the permission.PrefixReader interface
+func (s *Store) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	return s.tx(s.db).SubjectsWithPrefix(ctx, p, prefix)
+}
+
+// RolesOf implements the permission.StoreReader interface
+func (s *Store) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	return s.tx(s.db).RolesOf(ctx, subject)
+}
+
+// MembersOf implements the permission.StoreReader interface
+func (s *Store) MembersOf(ctx context.Context, role string) ([]string, error) {
+	return s.tx(s.db).MembersOf(ctx, role)
+}
+
+// Subjects implements the permission.StoreReader interface
+func (s *Store) Subjects(ctx context.Context) ([]string, error) {
+	return s.tx(s.db).Subjects(ctx)
+}
+
+// Update implements the permission.Store interface
+func (s *Store) Update(ctx context.Context, fn func(tx permission.StoreTx) error) error {
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	err = fn(s.tx(sqlTx))
+	if err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+// View implements the permission.Store interface.
+// On PostgreSQL, fn runs in a read-only repeatable read transaction
+func (s *Store) View(ctx context.Context, fn func(r permission.StoreReader) error) error {
+	var opts *sql.TxOptions
+	if s.dialect == Postgres {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	return fn(s.tx(sqlTx))
+}
+
+// rebind converts the ? placeholders of the query to the dialect placeholders
+func (s *Store) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+
+	return b.String()
+}
+
+func (s *Store) tx(q querier) *tx {
+	return &tx{q: q, store: s}
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type tx struct {
+	q     querier
+	store *Store
+}
+
+func (t *tx) exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := t.q.ExecContext(ctx, t.store.rebind(query), args...)
+	return err
+}
+
+func (t *tx) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.q.QueryContext(ctx, t.store.rebind(query), args...)
+}
+
+func (t *tx) Grant(ctx context.Context, subject string, scope permission.Scope) error {
+	err := permission.ValidateGrant(subject, scope)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range scope {
+		err = t.exec(ctx, `INSERT INTO permission_grants (subject, name, sub) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`, subject, p.Name, p.Sub)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Revoke(ctx context.Context, subject string, scope permission.Scope) error {
+	err := permission.ValidateGrant(subject, scope)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range scope {
+		err = t.exec(ctx, `DELETE FROM permission_grants WHERE subject = ? AND name = ? AND sub = ?`, subject, p.Name, p.Sub)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Assign(ctx context.Context, subject string, roles ...string) error {
+	err := permission.ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		err = t.exec(ctx, `INSERT INTO permission_roles (subject, role) VALUES (?, ?) ON CONFLICT DO NOTHING`, subject, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) Unassign(ctx context.Context, subject string, roles ...string) error {
+	err := permission.ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		err = t.exec(ctx, `DELETE FROM permission_roles WHERE subject = ? AND role = ?`, subject, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tx) ScopeOf(ctx context.Context, subject string) (permission.Scope, error) {
+	if subject == "" {
+		return nil, permission.ErrEmptySubject
+	}
+
+	rows, err := t.query(ctx, `SELECT name, sub FROM permission_grants WHERE subject = ?`, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scope permission.Scope
+	for rows.Next() {
+		var p permission.Permission
+		err = rows.Scan(&p.Name, &p.Sub)
+		if err != nil {
+			return nil, err
+		}
+		scope = append(scope, p)
+	}
+
+	// sorting in Go doesn't depend on the database collation
+	permission.SortScope(scope)
+	return scope, rows.Err()
+}
+
+func (t *tx) SubjectsWith(ctx context.Context, p permission.Permission) ([]string, error) {
+	if p.Name == "" {
+		return nil, permission.ErrEmptyName
+	}
+
+	rows, err := t.query(ctx, `SELECT subject FROM permission_grants WHERE name = ? AND sub = ?`, p.Name, p.Sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+func (t *tx) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	if p.Name == "" {
+		return nil, permission.ErrEmptyName
+	}
+
+	// unlike LIKE, substr doesn't depend on the case and has no wildcards
+	rows, err := t.query(ctx, `SELECT subject FROM permission_grants WHERE name = ? AND sub = ? AND substr(subject, 1, length(?)) = ?`, p.Name, p.Sub, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+func (t *tx) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	if subject == "" {
+		return nil, permission.ErrEmptySubject
+	}
+
+	rows, err := t.query(ctx, `SELECT role FROM permission_roles WHERE subject = ?`, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+func (t *tx) MembersOf(ctx context.Context, role string) ([]string, error) {
+	if role == "" {
+		return nil, permission.ErrEmptyRole
+	}
+
+	rows, err := t.query(ctx, `SELECT subject FROM permission_roles WHERE role = ?`, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+func (t *tx) Subjects(ctx context.Context) ([]string, error) {
+	rows, err := t.query(ctx, `SELECT subject FROM permission_grants UNION SELECT subject FROM permission_roles`)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var s string
+		err := rows.Scan(&s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+
+	sort.Strings(list)
+	return list, rows.Err()
+}
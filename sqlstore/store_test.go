@@ -0,0 +1,60 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openStore(t *testing.T) *Store {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// each connection to :memory: opens a distinct database
+	db.SetMaxOpenConns(1)
+
+	s := New(db, SQLite)
+	require.NoError(t, s.Migrate(context.Background()))
+	return s
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		return openStore(t)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	s := openStore(t)
+
+	version, err := s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	require.NoError(t, s.Grant(ctx, "alice", permission.Scope{{Name: "user"}}))
+	require.NoError(t, s.Migrate(ctx))
+
+	version, err = s.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, permission.Scope{{Name: "user"}}, granted)
+}
+
+func TestRebind(t *testing.T) {
+	s := New(nil, SQLite)
+	assert.Equal(t, "a = ? AND b = ?", s.rebind("a = ? AND b = ?"))
+
+	s = New(nil, Postgres)
+	assert.Equal(t, "a = $1 AND b = $2", s.rebind("a = ? AND b = ?"))
+}
@@ -0,0 +1,434 @@
+package permission
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StoreReader is the set of read operations available on a Store.
+// Subjects are opaque identifiers, like user ids or client ids
+type StoreReader interface {
+	// ScopeOf returns the sorted permissions granted to the subject
+	ScopeOf(ctx context.Context, subject string) (Scope, error)
+
+	// SubjectsWith returns the sorted subjects the given permission is granted to
+	SubjectsWith(ctx context.Context, p Permission) ([]string, error)
+
+	// RolesOf returns the sorted roles assigned to the subject
+	RolesOf(ctx context.Context, subject string) ([]string, error)
+
+	// MembersOf returns the sorted subjects the given role is assigned to
+	MembersOf(ctx context.Context, role string) ([]string, error)
+
+	// Subjects returns the sorted subjects that have at least one grant or one role
+	Subjects(ctx context.Context) ([]string, error)
+}
+
+// PrefixReader is implemented by the store readers that can list the subjects a permission
+// is granted to among the subjects starting with a prefix, without reading the others.
+// The partitions returned by TenantStore use it to only read the subjects of their tenant
+type PrefixReader interface {
+	// SubjectsWithPrefix returns the sorted subjects starting with the prefix the given permission is granted to
+	SubjectsWithPrefix(ctx context.Context, p Permission, prefix string) ([]string, error)
+}
+
+// SubjectsWithPrefix returns the sorted subjects starting with the prefix the given permission is granted to,
+// using PrefixReader if r implements it
+func SubjectsWithPrefix(ctx context.Context, r StoreReader, p Permission, prefix string) ([]string, error) {
+	if pr, ok := r.(PrefixReader); ok {
+		return pr.SubjectsWithPrefix(ctx, p, prefix)
+	}
+
+	subjects, err := r.SubjectsWith(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for _, subject := range subjects {
+		if strings.HasPrefix(subject, prefix) {
+			kept = append(kept, subject)
+		}
+	}
+	return kept, nil
+}
+
+// StoreTx is the set of operations available on the grants and role assignments of a Store.
+type StoreTx interface {
+	StoreReader
+
+	// Grant adds the permissions of the scope to the subject grants.
+	// Granting a permission that is already granted is not an error
+	Grant(ctx context.Context, subject string, s Scope) error
+
+	// Revoke removes the permissions of the scope from the subject grants.
+	// Revoking a permission that is not granted is not an error
+	Revoke(ctx context.Context, subject string, s Scope) error
+
+	// Assign assigns the roles to the subject.
+	// Assigning a role that is already assigned is not an error
+	Assign(ctx context.Context, subject string, roles ...string) error
+
+	// Unassign removes the roles from the subject.
+	// Removing a role that is not assigned is not an error
+	Unassign(ctx context.Context, subject string, roles ...string) error
+}
+
+// Store is a collection of subject grants and role assignments.
+type Store interface {
+	StoreTx
+
+	// Update runs fn in a transaction.
+	// If fn returns an error, none of its changes are applied
+	Update(ctx context.Context, fn func(tx StoreTx) error) error
+
+	// View runs fn on a consistent snapshot of the Store.
+	// Changes committed while fn runs are not visible to fn
+	View(ctx context.Context, fn func(r StoreReader) error) error
+}
+
+// ValidateGrant checks that the subject and the scope can be stored
+func ValidateGrant(subject string, s Scope) error {
+	if subject == "" {
+		return ErrEmptySubject
+	}
+
+	for _, p := range s {
+		if p.Name == "" {
+			return ErrEmptyName
+		}
+	}
+
+	return nil
+}
+
+// ValidateAssignment checks that the subject and the roles can be stored
+func ValidateAssignment(subject string, roles []string) error {
+	if subject == "" {
+		return ErrEmptySubject
+	}
+
+	for _, role := range roles {
+		if role == "" {
+			return ErrEmptyRole
+		}
+	}
+
+	return nil
+}
+
+// SortScope sorts the scope by name then by sub permission
+func SortScope(s Scope) {
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].Name != s[j].Name {
+			return s[i].Name < s[j].Name
+		}
+		return s[i].Sub < s[j].Sub
+	})
+}
+
+// MemoryStore is a Store that keeps the grants in memory.
+// It is safe for concurrent use
+type MemoryStore struct {
+	mu    sync.RWMutex
+	state memState
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{state: newMemState()}
+}
+
+// Grant implements the StoreTx interface
+func (m *MemoryStore) Grant(ctx context.Context, subject string, s Scope) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Grant(ctx, subject, s)
+}
+
+// Revoke implements the StoreTx interface
+func (m *MemoryStore) Revoke(ctx context.Context, subject string, s Scope) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Revoke(ctx, subject, s)
+}
+
+// Assign implements the StoreTx interface
+func (m *MemoryStore) Assign(ctx context.Context, subject string, roles ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Assign(ctx, subject, roles...)
+}
+
+// Unassign implements the StoreTx interface
+func (m *MemoryStore) Unassign(ctx context.Context, subject string, roles ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.Unassign(ctx, subject, roles...)
+}
+
+// ScopeOf implements the StoreReader interface
+func (m *MemoryStore) ScopeOf(ctx context.Context, subject string) (Scope, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ScopeOf(ctx, subject)
+}
+
+// SubjectsWith implements the StoreReader interface
+func (m *MemoryStore) SubjectsWith(ctx context.Context, p Permission) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.SubjectsWith(ctx, p)
+}
+
+// SubjectsWithPrefix implements the PrefixReader interface
+func (m *MemoryStore) SubjectsWithPrefix(ctx context.Context, p Permission, prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.SubjectsWithPrefix(ctx, p, prefix)
+}
+
+// RolesOf implements the StoreReader interface
+func (m *MemoryStore) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.RolesOf(ctx, subject)
+}
+
+// MembersOf implements the StoreReader interface
+func (m *MemoryStore) MembersOf(ctx context.Context, role string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.MembersOf(ctx, role)
+}
+
+// Subjects implements the StoreReader interface
+func (m *MemoryStore) Subjects(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Subjects(ctx)
+}
+
+// Update implements the Store interface.
+// fn must only use the given transaction, calling the MemoryStore from fn blocks forever
+func (m *MemoryStore) Update(ctx context.Context, fn func(tx StoreTx) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := m.state.clone()
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	m.state = tx
+	return nil
+}
+
+// View implements the Store interface.
+// Updates are blocked while fn runs
+func (m *MemoryStore) View(ctx context.Context, fn func(r StoreReader) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fn(m.state)
+}
+
+type memState struct {
+	grants map[string]map[Permission]struct{}
+	roles  map[string]map[string]struct{}
+}
+
+func newMemState() memState {
+	return memState{
+		grants: make(map[string]map[Permission]struct{}),
+		roles:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (m memState) clone() memState {
+	c := newMemState()
+	for subject, perms := range m.grants {
+		c.grants[subject] = make(map[Permission]struct{}, len(perms))
+		for p := range perms {
+			c.grants[subject][p] = struct{}{}
+		}
+	}
+	for subject, roles := range m.roles {
+		c.roles[subject] = make(map[string]struct{}, len(roles))
+		for role := range roles {
+			c.roles[subject][role] = struct{}{}
+		}
+	}
+	return c
+}
+
+func (m memState) Grant(ctx context.Context, subject string, s Scope) error {
+	err := ValidateGrant(subject, s)
+	if err != nil {
+		return err
+	}
+
+	perms, ok := m.grants[subject]
+	if !ok {
+		perms = make(map[Permission]struct{})
+		m.grants[subject] = perms
+	}
+
+	for _, p := range s {
+		perms[p] = struct{}{}
+	}
+
+	if len(perms) == 0 {
+		delete(m.grants, subject)
+	}
+
+	return nil
+}
+
+func (m memState) Revoke(ctx context.Context, subject string, s Scope) error {
+	err := ValidateGrant(subject, s)
+	if err != nil {
+		return err
+	}
+
+	perms := m.grants[subject]
+	for _, p := range s {
+		delete(perms, p)
+	}
+
+	if len(perms) == 0 {
+		delete(m.grants, subject)
+	}
+
+	return nil
+}
+
+func (m memState) Assign(ctx context.Context, subject string, roles ...string) error {
+	err := ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	assigned, ok := m.roles[subject]
+	if !ok {
+		assigned = make(map[string]struct{})
+		m.roles[subject] = assigned
+	}
+
+	for _, role := range roles {
+		assigned[role] = struct{}{}
+	}
+
+	if len(assigned) == 0 {
+		delete(m.roles, subject)
+	}
+
+	return nil
+}
+
+func (m memState) Unassign(ctx context.Context, subject string, roles ...string) error {
+	err := ValidateAssignment(subject, roles)
+	if err != nil {
+		return err
+	}
+
+	assigned := m.roles[subject]
+	for _, role := range roles {
+		delete(assigned, role)
+	}
+
+	if len(assigned) == 0 {
+		delete(m.roles, subject)
+	}
+
+	return nil
+}
+
+func (m memState) ScopeOf(ctx context.Context, subject string) (Scope, error) {
+	if subject == "" {
+		return nil, ErrEmptySubject
+	}
+
+	perms := m.grants[subject]
+	if len(perms) == 0 {
+		return nil, nil
+	}
+
+	s := make(Scope, 0, len(perms))
+	for p := range perms {
+		s = append(s, p)
+	}
+
+	SortScope(s)
+	return s, nil
+}
+
+func (m memState) SubjectsWith(ctx context.Context, p Permission) ([]string, error) {
+	return m.SubjectsWithPrefix(ctx, p, "")
+}
+
+func (m memState) SubjectsWithPrefix(ctx context.Context, p Permission, prefix string) ([]string, error) {
+	if p.Name == "" {
+		return nil, ErrEmptyName
+	}
+
+	var subjects []string
+	for subject, perms := range m.grants {
+		if !strings.HasPrefix(subject, prefix) {
+			continue
+		}
+		if _, ok := perms[p]; ok {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+func (m memState) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	if subject == "" {
+		return nil, ErrEmptySubject
+	}
+
+	var roles []string
+	for role := range m.roles[subject] {
+		roles = append(roles, role)
+	}
+
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func (m memState) MembersOf(ctx context.Context, role string) ([]string, error) {
+	if role == "" {
+		return nil, ErrEmptyRole
+	}
+
+	var subjects []string
+	for subject, roles := range m.roles {
+		if _, ok := roles[role]; ok {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+func (m memState) Subjects(ctx context.Context) ([]string, error) {
+	var subjects []string
+	for subject := range m.grants {
+		subjects = append(subjects, subject)
+	}
+	for subject := range m.roles {
+		if _, ok := m.grants[subject]; !ok {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	sort.Strings(subjects)
+	return subjects, nil
+}
@@ -0,0 +1,23 @@
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		return permission.NewMemoryStore()
+	})
+}
+
+func TestSortScope(t *testing.T) {
+	s, _ := permission.ParseScope("b,a.j,c.i,a,a.i")
+	permission.SortScope(s)
+
+	expected, _ := permission.ParseScope("a,a.i,a.j,b,c.i")
+	assert.Equal(t, expected, s)
+}
@@ -0,0 +1,382 @@
+// Package storetest provides a conformance suite for permission.Store implementations
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the conformance suite against the stores returned by open.
+// open is called once per test and must return an empty Store
+func Run(t *testing.T, open func(t *testing.T) permission.Store) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, permission.Store)
+	}{
+		{"Grant", testGrant},
+		{"Revoke", testRevoke},
+		{"SubjectsWith", testSubjectsWith},
+		{"Roles", testRoles},
+		{"Subjects", testSubjects},
+		{"Validation", testValidation},
+		{"UpdateCommit", testUpdateCommit},
+		{"UpdateRollback", testUpdateRollback},
+		{"View", testView},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, open(t))
+		})
+	}
+}
+
+func scope(t *testing.T, repr string) permission.Scope {
+	s, err := permission.ParseScope(repr)
+	require.NoError(t, err)
+	return s
+}
+
+func testGrant(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user.edit,playlist")))
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user.edit,admin")))
+	require.NoError(t, s.Grant(ctx, "bob", scope(t, "user.profile")))
+	require.NoError(t, s.Grant(ctx, "carol", permission.Scope{}))
+
+	granted, err = s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "admin,playlist,user.edit"), granted)
+
+	granted, err = s.ScopeOf(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "user.profile"), granted)
+
+	granted, err = s.ScopeOf(ctx, "carol")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+}
+
+func testRevoke(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user.edit,user,playlist")))
+	require.NoError(t, s.Grant(ctx, "bob", scope(t, "user.edit")))
+	require.NoError(t, s.Revoke(ctx, "alice", scope(t, "user.edit,admin")))
+	require.NoError(t, s.Revoke(ctx, "nobody", scope(t, "user.edit")))
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "playlist,user"), granted)
+
+	granted, err = s.ScopeOf(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "user.edit"), granted)
+
+	require.NoError(t, s.Revoke(ctx, "alice", scope(t, "playlist,user")))
+
+	granted, err = s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+}
+
+func testSubjectsWith(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Grant(ctx, "carol", scope(t, "user.edit")))
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user.edit,user")))
+	require.NoError(t, s.Grant(ctx, "bob", scope(t, "user.profile")))
+
+	subjects, err := s.SubjectsWith(ctx, permission.Permission{Name: "user", Sub: "edit"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol"}, subjects)
+
+	subjects, err = s.SubjectsWith(ctx, permission.Permission{Name: "user"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, subjects)
+
+	subjects, err = s.SubjectsWith(ctx, permission.Permission{Name: "playlist"})
+	require.NoError(t, err)
+	assert.Len(t, subjects, 0)
+
+	// prefixes are matched byte by byte, without wildcards, by the stores implementing permission.PrefixReader
+	for _, subject := range []string{"acme/alice", "acme/bob", "acmes/carol", "ACME/dave", "a%/erin", "a_/frank"} {
+		require.NoError(t, s.Grant(ctx, subject, scope(t, "user.edit")))
+	}
+	require.NoError(t, s.Grant(ctx, "acme/greg", scope(t, "user")))
+
+	edit := permission.Permission{Name: "user", Sub: "edit"}
+	tests := map[string][]string{
+		"acme/": {"acme/alice", "acme/bob"},
+		"a%":    {"a%/erin"},
+		"a_":    {"a_/frank"},
+		"nope/": nil,
+		"":      {"ACME/dave", "a%/erin", "a_/frank", "acme/alice", "acme/bob", "acmes/carol", "alice", "carol"},
+	}
+	for prefix, expected := range tests {
+		subjects, err = permission.SubjectsWithPrefix(ctx, s, edit, prefix)
+		require.NoError(t, err)
+		assert.Equal(t, expected, subjects, prefix)
+	}
+
+	_, err = permission.SubjectsWithPrefix(ctx, s, permission.Permission{}, "acme/")
+	assert.Equal(t, permission.ErrEmptyName, err)
+
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		subjects, err := permission.SubjectsWithPrefix(ctx, r, edit, "acme/")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme/alice", "acme/bob"}, subjects)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func testRoles(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	roles, err := s.RolesOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, roles, 0)
+
+	require.NoError(t, s.Assign(ctx, "alice", "editor", "admin"))
+	require.NoError(t, s.Assign(ctx, "alice", "admin"))
+	require.NoError(t, s.Assign(ctx, "bob", "editor", "viewer"))
+	require.NoError(t, s.Assign(ctx, "carol"))
+
+	roles, err = s.RolesOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "editor"}, roles)
+
+	members, err := s.MembersOf(ctx, "editor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, members)
+
+	require.NoError(t, s.Unassign(ctx, "bob", "editor", "admin"))
+	require.NoError(t, s.Unassign(ctx, "nobody", "editor"))
+
+	roles, err = s.RolesOf(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, roles)
+
+	members, err = s.MembersOf(ctx, "editor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, members)
+
+	members, err = s.MembersOf(ctx, "owner")
+	require.NoError(t, err)
+	assert.Len(t, members, 0)
+
+	// roles and grants are independent
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+}
+
+func testSubjects(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	subjects, err := s.Subjects(ctx)
+	require.NoError(t, err)
+	assert.Len(t, subjects, 0)
+
+	require.NoError(t, s.Grant(ctx, "carol", scope(t, "user")))
+	require.NoError(t, s.Assign(ctx, "alice", "admin"))
+	require.NoError(t, s.Grant(ctx, "bob", scope(t, "user")))
+	require.NoError(t, s.Assign(ctx, "bob", "admin"))
+	require.NoError(t, s.Grant(ctx, "dave", scope(t, "user")))
+	require.NoError(t, s.Revoke(ctx, "dave", scope(t, "user")))
+
+	subjects, err = s.Subjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, subjects)
+}
+
+func testValidation(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	assert.Equal(t, permission.ErrEmptySubject, s.Grant(ctx, "", scope(t, "user")))
+	assert.Equal(t, permission.ErrEmptySubject, s.Revoke(ctx, "", scope(t, "user")))
+	assert.Equal(t, permission.ErrEmptyName, s.Grant(ctx, "alice", permission.Scope{{Sub: "edit"}}))
+
+	_, err := s.ScopeOf(ctx, "")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+
+	_, err = s.SubjectsWith(ctx, permission.Permission{})
+	assert.Equal(t, permission.ErrEmptyName, err)
+
+	assert.Equal(t, permission.ErrEmptySubject, s.Assign(ctx, "", "admin"))
+	assert.Equal(t, permission.ErrEmptySubject, s.Unassign(ctx, "", "admin"))
+	assert.Equal(t, permission.ErrEmptyRole, s.Assign(ctx, "alice", "admin", ""))
+
+	_, err = s.RolesOf(ctx, "")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+
+	_, err = s.MembersOf(ctx, "")
+	assert.Equal(t, permission.ErrEmptyRole, err)
+
+	roles, err := s.RolesOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, roles, 0)
+
+	err = s.Grant(ctx, "alice", permission.Scope{{Name: "user"}, {}})
+	assert.Equal(t, permission.ErrEmptyName, err)
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, granted, 0)
+}
+
+func testUpdateCommit(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user")))
+
+	err := s.Update(ctx, func(tx permission.StoreTx) error {
+		err := tx.Grant(ctx, "alice", scope(t, "user.edit"))
+		if err != nil {
+			return err
+		}
+
+		err = tx.Revoke(ctx, "alice", scope(t, "user"))
+		if err != nil {
+			return err
+		}
+
+		granted, err := tx.ScopeOf(ctx, "alice")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, scope(t, "user.edit"), granted)
+
+		err = tx.Assign(ctx, "alice", "admin")
+		if err != nil {
+			return err
+		}
+
+		return tx.Grant(ctx, "bob", scope(t, "user.edit"))
+	})
+	require.NoError(t, err)
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "user.edit"), granted)
+
+	subjects, err := s.SubjectsWith(ctx, permission.Permission{Name: "user", Sub: "edit"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, subjects)
+
+	roles, err := s.RolesOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, roles)
+}
+
+func testUpdateRollback(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user")))
+
+	err := s.Update(ctx, func(tx permission.StoreTx) error {
+		err := tx.Grant(ctx, "alice", scope(t, "user.edit"))
+		if err != nil {
+			return err
+		}
+
+		err = tx.Revoke(ctx, "alice", scope(t, "user"))
+		if err != nil {
+			return err
+		}
+
+		err = tx.Assign(ctx, "alice", "admin")
+		if err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "user"), granted)
+
+	roles, err := s.RolesOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, roles, 0)
+}
+
+func testView(t *testing.T, s permission.Store) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	require.NoError(t, s.Grant(ctx, "alice", scope(t, "user")))
+	require.NoError(t, s.Assign(ctx, "bob", "admin"))
+
+	err := s.View(ctx, func(r permission.StoreReader) error {
+		granted, err := r.ScopeOf(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, scope(t, "user"), granted)
+
+		subjects, err := r.SubjectsWith(ctx, permission.Permission{Name: "user"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, subjects)
+
+		roles, err := r.RolesOf(ctx, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, roles)
+
+		members, err := r.MembersOf(ctx, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, members)
+
+		subjects, err = r.Subjects(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, subjects)
+
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+
+	// changes committed while fn runs are not visible to fn
+	var committed error
+	done := make(chan struct{})
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		go func() {
+			committed = s.Grant(ctx, "alice", scope(t, "admin"))
+			close(done)
+		}()
+
+		// stores blocking updates during View commit once fn returns
+		select {
+		case <-done:
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		granted, err := r.ScopeOf(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, scope(t, "user"), granted)
+
+		subjects, err := r.SubjectsWith(ctx, permission.Permission{Name: "admin"})
+		require.NoError(t, err)
+		assert.Len(t, subjects, 0)
+		return nil
+	})
+	require.NoError(t, err)
+
+	<-done
+	require.NoError(t, committed)
+	granted, err := s.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, scope(t, "admin,user"), granted)
+}
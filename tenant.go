@@ -0,0 +1,245 @@
+package permission
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// tenantSeparator separates the tenant from the subject in the keys of a partitioned Store.
+// It is forbidden in tenant names so that keys are unambiguous
+const tenantSeparator = "/"
+
+// ValidateTenant checks that the tenant name can be used to partition a Store
+func ValidateTenant(tenant string) error {
+	if tenant == "" {
+		return ErrEmptyTenant
+	}
+
+	if strings.Contains(tenant, tenantSeparator) {
+		return ErrBadFormat
+	}
+
+	return nil
+}
+
+// TenantStore returns the partition of the store that belongs to the tenant.
+// Subjects of a partition are invisible to the other partitions.
+// Roles are shared by all tenants but their assignments are not.
+//
+// Partitions are only isolated by prefixing the subjects with the tenant and a slash:
+// the subject acme/bob written to the store directly is the subject bob of the tenant acme.
+// A store partitioned by tenant must only be accessed through TenantStore,
+// never with subjects coming from users.
+// Readers implementing PrefixReader only read the subjects of the tenant
+func TenantStore(s Store, tenant string) (Store, error) {
+	err := ValidateTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenantStore{
+		tenantTx: tenantTx{tenantReader: tenantReader{r: s, prefix: tenant + tenantSeparator}, tx: s},
+		store:    s,
+	}, nil
+}
+
+type tenantReader struct {
+	r      StoreReader
+	prefix string
+}
+
+func (t tenantReader) key(subject string) (string, error) {
+	if subject == "" {
+		return "", ErrEmptySubject
+	}
+
+	return t.prefix + subject, nil
+}
+
+// subjects returns the subjects of the partition, sorted
+func (t tenantReader) subjects(keys []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, t.prefix) {
+			subjects = append(subjects, key[len(t.prefix):])
+		}
+	}
+	return subjects, nil
+}
+
+func (t tenantReader) ScopeOf(ctx context.Context, subject string) (Scope, error) {
+	key, err := t.key(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.r.ScopeOf(ctx, key)
+}
+
+func (t tenantReader) SubjectsWith(ctx context.Context, p Permission) ([]string, error) {
+	return t.subjects(SubjectsWithPrefix(ctx, t.r, p, t.prefix))
+}
+
+func (t tenantReader) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	key, err := t.key(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.r.RolesOf(ctx, key)
+}
+
+func (t tenantReader) MembersOf(ctx context.Context, role string) ([]string, error) {
+	return t.subjects(t.r.MembersOf(ctx, role))
+}
+
+func (t tenantReader) Subjects(ctx context.Context) ([]string, error) {
+	return t.subjects(t.r.Subjects(ctx))
+}
+
+type tenantTx struct {
+	tenantReader
+	tx StoreTx
+}
+
+func (t tenantTx) Grant(ctx context.Context, subject string, s Scope) error {
+	key, err := t.key(subject)
+	if err != nil {
+		return err
+	}
+
+	return t.tx.Grant(ctx, key, s)
+}
+
+func (t tenantTx) Revoke(ctx context.Context, subject string, s Scope) error {
+	key, err := t.key(subject)
+	if err != nil {
+		return err
+	}
+
+	return t.tx.Revoke(ctx, key, s)
+}
+
+func (t tenantTx) Assign(ctx context.Context, subject string, roles ...string) error {
+	key, err := t.key(subject)
+	if err != nil {
+		return err
+	}
+
+	return t.tx.Assign(ctx, key, roles...)
+}
+
+func (t tenantTx) Unassign(ctx context.Context, subject string, roles ...string) error {
+	key, err := t.key(subject)
+	if err != nil {
+		return err
+	}
+
+	return t.tx.Unassign(ctx, key, roles...)
+}
+
+type tenantStore struct {
+	tenantTx
+	store Store
+}
+
+func (t *tenantStore) Update(ctx context.Context, fn func(tx StoreTx) error) error {
+	return t.store.Update(ctx, func(tx StoreTx) error {
+		return fn(tenantTx{tenantReader: tenantReader{r: tx, prefix: t.prefix}, tx: tx})
+	})
+}
+
+func (t *tenantStore) View(ctx context.Context, fn func(r StoreReader) error) error {
+	return t.store.View(ctx, func(r StoreReader) error {
+		return fn(tenantReader{r: r, prefix: t.prefix})
+	})
+}
+
+// MultiTenant evaluates the permissions of subjects that belong to several tenants.
+// A subject can have different grants and roles in each tenant,
+// and tenants can override the default definitions.
+// It is safe for concurrent use
+type MultiTenant struct {
+	store Store
+	defs  Definitions
+	roles Roles
+
+	mu        sync.RWMutex
+	overrides map[string]Definitions
+}
+
+// NewMultiTenant returns a MultiTenant that partitions the store by tenant
+// and uses the given definitions and roles by default
+func NewMultiTenant(store Store, defs Definitions, roles Roles) *MultiTenant {
+	return &MultiTenant{
+		store:     store,
+		defs:      defs,
+		roles:     roles,
+		overrides: make(map[string]Definitions),
+	}
+}
+
+// Override replaces the definitions used for the tenant.
+// A nil Definitions restores the default definitions
+func (m *MultiTenant) Override(tenant string, defs Definitions) error {
+	err := ValidateTenant(tenant)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if defs == nil {
+		delete(m.overrides, tenant)
+	} else {
+		m.overrides[tenant] = defs
+	}
+	return nil
+}
+
+// DefinitionsOf returns the definitions used for the tenant
+func (m *MultiTenant) DefinitionsOf(tenant string) Definitions {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if defs, ok := m.overrides[tenant]; ok {
+		return defs
+	}
+	return m.defs
+}
+
+// Tenant returns the partition of the store that belongs to the tenant
+func (m *MultiTenant) Tenant(tenant string) (Store, error) {
+	return TenantStore(m.store, tenant)
+}
+
+// Require checks wether the permissions granted to the subject in the tenant,
+// directly or through its roles, match the required permission and are listed in the tenant definitions.
+// Returns an error if the parsing or the store lookup fails
+func (m *MultiTenant) Require(ctx context.Context, tenant, subject, required string) (bool, error) {
+	req, err := ParseScope(required)
+	if err != nil {
+		fail(ctx, tenant, subject, err)
+		return false, err
+	}
+
+	store, err := m.Tenant(tenant)
+	if err != nil {
+		fail(ctx, tenant, subject, err)
+		return false, err
+	}
+
+	s, err := EffectiveScope(ctx, store, m.roles, subject)
+	if err != nil {
+		fail(ctx, tenant, subject, err)
+		return false, err
+	}
+
+	return m.DefinitionsOf(tenant).decideFor(ctx, tenant, subject, req, s).Allowed, nil
+}
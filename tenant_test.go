@@ -0,0 +1,201 @@
+package permission_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		shared := permission.NewMemoryStore()
+
+		// noise in another partition must not be visible
+		other, err := permission.TenantStore(shared, "other")
+		require.NoError(t, err)
+		require.NoError(t, other.Grant(context.Background(), "alice", permission.Scope{{Name: "user", Sub: "edit"}}))
+		require.NoError(t, other.Assign(context.Background(), "alice", "admin", "editor"))
+
+		s, err := permission.TenantStore(shared, "acme")
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestTenantStoreValidation(t *testing.T) {
+	_, err := permission.TenantStore(permission.NewMemoryStore(), "")
+	assert.Equal(t, permission.ErrEmptyTenant, err)
+
+	_, err = permission.TenantStore(permission.NewMemoryStore(), "acme/eu")
+	assert.Equal(t, permission.ErrBadFormat, err)
+}
+
+func TestTenantStoreIsolation(t *testing.T) {
+	ctx := context.Background()
+	shared := permission.NewMemoryStore()
+
+	ac, err := permission.TenantStore(shared, "ac")
+	require.NoError(t, err)
+	acme, err := permission.TenantStore(shared, "acme")
+	require.NoError(t, err)
+
+	// "ac" + "me/alice" and "acme" + "alice" must not collide
+	require.NoError(t, ac.Grant(ctx, "me/alice", permission.Scope{{Name: "admin"}}))
+	require.NoError(t, acme.Grant(ctx, "alice", permission.Scope{{Name: "user"}}))
+
+	s, err := acme.ScopeOf(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, permission.Scope{{Name: "user"}}, s)
+
+	s, err = ac.ScopeOf(ctx, "me/alice")
+	require.NoError(t, err)
+	assert.Equal(t, permission.Scope{{Name: "admin"}}, s)
+
+	subjects, err := acme.SubjectsWith(ctx, permission.Permission{Name: "admin"})
+	require.NoError(t, err)
+	assert.Len(t, subjects, 0)
+
+	subjects, err = ac.Subjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me/alice"}, subjects)
+
+	subjects, err = acme.Subjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, subjects)
+
+	// partitions are only isolated by the prefix of the subjects
+	require.NoError(t, shared.Grant(ctx, "acme/bob", permission.Scope{{Name: "user"}}))
+	subjects, err = acme.Subjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, subjects)
+}
+
+// prefixSpy records the prefixes the subjects are listed with
+type prefixSpy struct {
+	permission.Store
+	prefixes []string
+}
+
+func (s *prefixSpy) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	return permission.SubjectsWithPrefix(ctx, s.Store, p, prefix)
+}
+
+func TestTenantStorePrefixReader(t *testing.T) {
+	ctx := context.Background()
+	spy := prefixSpy{Store: permission.NewMemoryStore()}
+	require.NoError(t, spy.Grant(ctx, "acme/alice", permission.Scope{{Name: "user"}}))
+	require.NoError(t, spy.Grant(ctx, "other/bob", permission.Scope{{Name: "user"}}))
+
+	acme, err := permission.TenantStore(&spy, "acme")
+	require.NoError(t, err)
+
+	subjects, err := acme.SubjectsWith(ctx, permission.Permission{Name: "user"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, subjects)
+	assert.Equal(t, []string{"acme/"}, spy.prefixes)
+}
+
+func TestMultiTenant(t *testing.T) {
+	ctx := context.Background()
+
+	defs := permission.Definitions{
+		{Name: "admin"},
+		{
+			Name:          "user",
+			Subset:        []string{"profile", "edit"},
+			DefaultSubset: []string{"profile"},
+		},
+	}
+	roles := permission.Roles{
+		{Name: "owner", Scope: permission.Scope{{Name: "admin"}}},
+	}
+
+	m := permission.NewMultiTenant(permission.NewMemoryStore(), defs, roles)
+
+	a, err := m.Tenant("a")
+	require.NoError(t, err)
+	b, err := m.Tenant("b")
+	require.NoError(t, err)
+
+	require.NoError(t, a.Assign(ctx, "alice", "owner"))
+	require.NoError(t, b.Grant(ctx, "alice", permission.Scope{{Name: "user", Sub: "profile"}}))
+
+	ok, err := m.Require(ctx, "a", "alice", "admin")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = m.Require(ctx, "b", "alice", "admin")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = m.Require(ctx, "b", "alice", "user.profile")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = m.Require(ctx, "a", "alice", "user.profile")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = m.Require(ctx, "c", "alice", "user.profile")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = m.Require(ctx, "", "alice", "admin")
+	assert.Equal(t, permission.ErrEmptyTenant, err)
+
+	_, err = m.Require(ctx, "a", "", "admin")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+
+	_, err = m.Require(ctx, "a", "alice", "admin,")
+	assert.Error(t, err)
+}
+
+func TestMultiTenantOverride(t *testing.T) {
+	ctx := context.Background()
+
+	defs := permission.Definitions{
+		{
+			Name:          "user",
+			Subset:        []string{"profile", "edit"},
+			DefaultSubset: []string{"profile"},
+		},
+	}
+
+	m := permission.NewMultiTenant(permission.NewMemoryStore(), defs, nil)
+	for _, tenant := range []string{"a", "b"} {
+		s, err := m.Tenant(tenant)
+		require.NoError(t, err)
+		require.NoError(t, s.Grant(ctx, "alice", permission.Scope{{Name: "user"}}))
+	}
+
+	require.NoError(t, m.Override("b", permission.Definitions{
+		{
+			Name:          "user",
+			Subset:        []string{"profile", "edit"},
+			DefaultSubset: []string{"profile", "edit"},
+		},
+	}))
+
+	assert.Equal(t, defs, m.DefinitionsOf("a"))
+
+	ok, err := m.Require(ctx, "a", "alice", "user.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = m.Require(ctx, "b", "alice", "user.edit")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, m.Override("b", nil))
+
+	ok, err = m.Require(ctx, "b", "alice", "user.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Equal(t, permission.ErrEmptyTenant, m.Override("", defs))
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/asdine/permission"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewStore wraps the store to create a span around every operation.
+// If tp is nil, the global TracerProvider is used
+func NewStore(s permission.Store, tp trace.TracerProvider) permission.Store {
+	t := tracer(tp)
+	return &store{tx: tx{reader: reader{r: s, tracer: t}, tx: s}, store: s}
+}
+
+type reader struct {
+	r      permission.StoreReader
+	tracer trace.Tracer
+}
+
+func (r reader) start(ctx context.Context, name string, subject string) (context.Context, trace.Span) {
+	var opts []trace.SpanStartOption
+	if subject != "" {
+		opts = append(opts, trace.WithAttributes(SubjectKey.String(subject)))
+	}
+	return r.tracer.Start(ctx, "permission.Store."+name, opts...)
+}
+
+// end records the error, if any, and ends the span
+func end(span trace.Span, err error) {
+	if err != nil {
+		setError(span, err)
+	}
+	span.End()
+}
+
+func (r reader) ScopeOf(ctx context.Context, subject string) (s permission.Scope, err error) {
+	ctx, span := r.start(ctx, "ScopeOf", subject)
+	defer func() { end(span, err) }()
+
+	return r.r.ScopeOf(ctx, subject)
+}
+
+func (r reader) SubjectsWith(ctx context.Context, p permission.Permission) (subjects []string, err error) {
+	ctx, span := r.start(ctx, "SubjectsWith", "")
+	defer func() { end(span, err) }()
+
+	return r.r.SubjectsWith(ctx, p)
+}
+
+func (r reader) SubjectsWithPrefix(ctx context.Context, p permission.Permission, prefix string) (subjects []string, err error) {
+	ctx, span := r.start(ctx, "SubjectsWithPrefix", "")
+	defer func() { end(span, err) }()
+
+	return permission.SubjectsWithPrefix(ctx, r.r, p, prefix)
+}
+
+func (r reader) RolesOf(ctx context.Context, subject string) (roles []string, err error) {
+	ctx, span := r.start(ctx, "RolesOf", subject)
+	defer func() { end(span, err) }()
+
+	return r.r.RolesOf(ctx, subject)
+}
+
+func (r reader) MembersOf(ctx context.Context, role string) (subjects []string, err error) {
+	ctx, span := r.start(ctx, "MembersOf", "")
+	defer func() { end(span, err) }()
+
+	return r.r.MembersOf(ctx, role)
+}
+
+func (r reader) Subjects(ctx context.Context) (subjects []string, err error) {
+	ctx, span := r.start(ctx, "Subjects", "")
+	defer func() { end(span, err) }()
+
+	return r.r.Subjects(ctx)
+}
+
+type tx struct {
+	reader
+	tx permission.StoreTx
+}
+
+func (t tx) Grant(ctx context.Context, subject string, s permission.Scope) (err error) {
+	ctx, span := t.start(ctx, "Grant", subject)
+	defer func() { end(span, err) }()
+
+	return t.tx.Grant(ctx, subject, s)
+}
+
+func (t tx) Revoke(ctx context.Context, subject string, s permission.Scope) (err error) {
+	ctx, span := t.start(ctx, "Revoke", subject)
+	defer func() { end(span, err) }()
+
+	return t.tx.Revoke(ctx, subject, s)
+}
+
+func (t tx) Assign(ctx context.Context, subject string, roles ...string) (err error) {
+	ctx, span := t.start(ctx, "Assign", subject)
+	defer func() { end(span, err) }()
+
+	return t.tx.Assign(ctx, subject, roles...)
+}
+
+func (t tx) Unassign(ctx context.Context, subject string, roles ...string) (err error) {
+	ctx, span := t.start(ctx, "Unassign", subject)
+	defer func() { end(span, err) }()
+
+	return t.tx.Unassign(ctx, subject, roles...)
+}
+
+type store struct {
+	tx
+	store permission.Store
+}
+
+func (s *store) Update(ctx context.Context, fn func(tx permission.StoreTx) error) (err error) {
+	ctx, span := s.start(ctx, "Update", "")
+	defer func() { end(span, err) }()
+
+	return s.store.Update(ctx, func(t permission.StoreTx) error {
+		return fn(tx{reader: reader{r: t, tracer: s.tracer}, tx: t})
+	})
+}
+
+func (s *store) View(ctx context.Context, fn func(r permission.StoreReader) error) (err error) {
+	ctx, span := s.start(ctx, "View", "")
+	defer func() { end(span, err) }()
+
+	return s.store.View(ctx, func(r permission.StoreReader) error {
+		return fn(reader{r: r, tracer: s.tracer})
+	})
+}
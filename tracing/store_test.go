@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/asdine/permission/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) permission.Store {
+		tp, _ := newProvider()
+		return NewStore(permission.NewMemoryStore(), tp)
+	})
+}
+
+func TestStoreSpans(t *testing.T) {
+	ctx := context.Background()
+	tp, exporter := newProvider()
+	s := NewStore(permission.NewMemoryStore(), tp)
+
+	err := s.Update(ctx, func(tx permission.StoreTx) error {
+		err := tx.Grant(ctx, "alice", permission.Scope{{Name: "user"}})
+		if err != nil {
+			return err
+		}
+		return tx.Assign(ctx, "alice", "admin")
+	})
+	require.NoError(t, err)
+
+	err = s.View(ctx, func(r permission.StoreReader) error {
+		_, err := r.Subjects(ctx)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"permission.Store.Grant",
+		"permission.Store.Assign",
+		"permission.Store.Update",
+		"permission.Store.Subjects",
+		"permission.Store.View",
+	}, names(exporter.GetSpans()))
+}
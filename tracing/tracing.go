@@ -0,0 +1,153 @@
+// Package tracing creates OpenTelemetry spans around scope parsing, store lookups and evaluations.
+//
+// It only uses the OpenTelemetry API: spans are not recorded unless a TracerProvider is configured.
+package tracing
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/asdine/permission"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer
+const InstrumentationName = "github.com/asdine/permission/tracing"
+
+// Attribute keys
+const (
+	RequiredKey = attribute.Key("permission.required")
+	AllowedKey  = attribute.Key("permission.allowed")
+	ReasonKey   = attribute.Key("permission.reason")
+	MatchKey    = attribute.Key("permission.match")
+	SubjectKey  = attribute.Key("permission.subject")
+	InputKey    = attribute.Key("permission.input")
+)
+
+// MaxInputSize is the maximum number of bytes of the raw input recorded by the InputKey attribute.
+// Longer inputs are truncated
+const MaxInputSize = 256
+
+// Tracer evaluates permissions against definitions and traces the evaluations
+type Tracer struct {
+	defs   permission.Definitions
+	tracer trace.Tracer
+}
+
+// New returns a Tracer for the definitions.
+// If tp is nil, the global TracerProvider is used
+func New(defs permission.Definitions, tp trace.TracerProvider) *Tracer {
+	return &Tracer{defs: defs, tracer: tracer(tp)}
+}
+
+func tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
+
+// ParseScope parses the scope in a permission.ParseScope span
+func (t *Tracer) ParseScope(ctx context.Context, repr string) (permission.Scope, error) {
+	_, span := t.tracer.Start(ctx, "permission.ParseScope", trace.WithAttributes(InputKey.String(truncate(repr))))
+	defer span.End()
+
+	s, err := permission.ParseScope(repr)
+	if err != nil {
+		setError(span, err)
+	}
+	return s, err
+}
+
+// Require parses the required permissions and the scope and evaluates them in a permission.Require span.
+// Returns false if the parsing fails, after notifying the decision hooks like permission.Definitions.Require
+func (t *Tracer) Require(ctx context.Context, required, scope string) bool {
+	ctx, span := t.tracer.Start(ctx, "permission.Require")
+	defer span.End()
+
+	req, err := t.ParseScope(ctx, required)
+	if err != nil {
+		fail(ctx, span, "", err)
+		return false
+	}
+
+	s, err := t.ParseScope(ctx, scope)
+	if err != nil {
+		fail(ctx, span, "", err)
+		return false
+	}
+
+	return t.Decide(ctx, "", req, s).Allowed
+}
+
+// RequireSubject parses the required permissions and evaluates them against the scope
+// of the subject, granted directly or through its roles, in a permission.RequireSubject span.
+// Wrap the reader with NewStore to trace the lookups.
+// Returns an error if the parsing or the lookups fail, after notifying the decision hooks
+func (t *Tracer) RequireSubject(ctx context.Context, r permission.StoreReader, roles permission.Roles, subject, required string) (bool, error) {
+	ctx, span := t.tracer.Start(ctx, "permission.RequireSubject", trace.WithAttributes(SubjectKey.String(subject)))
+	defer span.End()
+
+	req, err := t.ParseScope(ctx, required)
+	if err != nil {
+		fail(ctx, span, subject, err)
+		return false, err
+	}
+
+	s, err := permission.EffectiveScope(ctx, r, roles, subject)
+	if err != nil {
+		fail(ctx, span, subject, err)
+		return false, err
+	}
+
+	return t.Decide(ctx, subject, req, s).Allowed, nil
+}
+
+// Decide evaluates the required permissions against the scope in a permission.Evaluate span
+// annotated with the required permissions and the outcome
+func (t *Tracer) Decide(ctx context.Context, subject string, required, scope permission.Scope) permission.Decision {
+	ctx, span := t.tracer.Start(ctx, "permission.Evaluate", trace.WithAttributes(RequiredKey.StringSlice(toStrings(required))))
+	defer span.End()
+
+	d := t.defs.Decide(ctx, subject, required, scope)
+	span.SetAttributes(AllowedKey.Bool(d.Allowed), ReasonKey.String(string(d.Reason)))
+	if d.Allowed {
+		span.SetAttributes(MatchKey.String(d.Match.String()))
+	}
+	return d
+}
+
+// fail marks the span as failed and notifies the decision hooks of the failed evaluation
+func fail(ctx context.Context, span trace.Span, subject string, err error) {
+	setError(span, err)
+	permission.Fail(ctx, subject, err)
+}
+
+func setError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// truncate returns the first MaxInputSize bytes of the input, without splitting a rune
+func truncate(input string) string {
+	if len(input) <= MaxInputSize {
+		return input
+	}
+
+	n := MaxInputSize
+	for n > 0 && !utf8.RuneStart(input[n]) {
+		n--
+	}
+	return input[:n]
+}
+
+func toStrings(s permission.Scope) []string {
+	list := make([]string, len(s))
+	for i, p := range s {
+		list[i] = p.String()
+	}
+	return list
+}
@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/asdine/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var testDefs = permission.Definitions{
+	{Name: "user", Subset: []string{"edit", "profile"}, DefaultSubset: []string{"profile"}},
+}
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func names(spans tracetest.SpanStubs) []string {
+	var list []string
+	for _, s := range spans {
+		list = append(list, s.Name)
+	}
+	return list
+}
+
+func TestRequire(t *testing.T) {
+	tp, exporter := newProvider()
+	tr := New(testDefs, tp)
+
+	assert.True(t, tr.Require(context.Background(), "user.profile", "user"))
+
+	spans := exporter.GetSpans()
+	// spans are exported when they end, children first
+	assert.Equal(t, []string{"permission.ParseScope", "permission.ParseScope", "permission.Evaluate", "permission.Require"}, names(spans))
+
+	root := spans[3]
+	for _, s := range spans[:3] {
+		assert.Equal(t, root.SpanContext.SpanID(), s.Parent.SpanID())
+		assert.Equal(t, root.SpanContext.TraceID(), s.SpanContext.TraceID())
+	}
+
+	assert.Equal(t, "user.profile", attrs(spans[0])[InputKey].AsString())
+	eval := attrs(spans[2])
+	assert.Equal(t, []string{"user.profile"}, eval[RequiredKey].AsStringSlice())
+	assert.True(t, eval[AllowedKey].AsBool())
+	assert.Equal(t, "granted", eval[ReasonKey].AsString())
+	assert.Equal(t, "user", eval[MatchKey].AsString())
+
+	exporter.Reset()
+	assert.False(t, tr.Require(context.Background(), "user.", "user"))
+	spans = exporter.GetSpans()
+	assert.Equal(t, []string{"permission.ParseScope", "permission.Require"}, names(spans))
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+
+	exporter.Reset()
+	assert.False(t, tr.Require(context.Background(), "user.edit", "user,"))
+	assert.Len(t, exporter.GetSpans(), 3)
+}
+
+func TestRequireSubject(t *testing.T) {
+	ctx := context.Background()
+	tp, exporter := newProvider()
+	tr := New(testDefs, tp)
+
+	s := permission.NewMemoryStore()
+	require.NoError(t, s.Grant(ctx, "alice", permission.Scope{{Name: "user", Sub: "profile"}}))
+	store := NewStore(s, tp)
+
+	ok, err := tr.RequireSubject(ctx, store, nil, "alice", "user.edit")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, []string{
+		"permission.ParseScope",
+		"permission.Store.ScopeOf",
+		"permission.Store.RolesOf",
+		"permission.Evaluate",
+		"permission.RequireSubject",
+	}, names(spans))
+	assert.Equal(t, "alice", attrs(spans[1])[SubjectKey].AsString())
+	assert.Equal(t, "alice", attrs(spans[4])[SubjectKey].AsString())
+	assert.False(t, attrs(spans[3])[AllowedKey].AsBool())
+	assert.Equal(t, "not granted", attrs(spans[3])[ReasonKey].AsString())
+	for _, s := range spans[:4] {
+		assert.Equal(t, spans[4].SpanContext.SpanID(), s.Parent.SpanID())
+	}
+
+	exporter.Reset()
+	_, err = tr.RequireSubject(ctx, store, nil, "", "user.edit")
+	assert.Equal(t, permission.ErrEmptySubject, err)
+	spans = exporter.GetSpans()
+	assert.Equal(t, codes.Error, spans[len(spans)-1].Status.Code)
+}
+
+func TestRequireHooks(t *testing.T) {
+	var decisions []permission.Decision
+	permission.OnDecision(permission.DecisionHookFunc(func(ctx context.Context, d permission.Decision) {
+		decisions = append(decisions, d)
+	}))
+	defer permission.OnDecision()
+
+	tp, _ := newProvider()
+	tr := New(testDefs, tp)
+
+	assert.True(t, tr.Require(context.Background(), "user.profile", "user"))
+	assert.False(t, tr.Require(context.Background(), "user.", "user"))
+	assert.False(t, tr.Require(context.Background(), "user.edit", "user,"))
+
+	require.Len(t, decisions, 3)
+	assert.Equal(t, permission.ReasonGranted, decisions[0].Reason)
+	for _, d := range decisions[1:] {
+		assert.Equal(t, permission.ReasonError, d.Reason)
+		assert.Error(t, d.Err)
+	}
+
+	_, err := tr.RequireSubject(context.Background(), permission.NewMemoryStore(), nil, "alice", "user.")
+	assert.Equal(t, permission.ErrBadFormat, err)
+	require.Len(t, decisions, 4)
+	assert.Equal(t, permission.ReasonError, decisions[3].Reason)
+	assert.Equal(t, "alice", decisions[3].Subject)
+}
+
+func TestInputTruncated(t *testing.T) {
+	tp, exporter := newProvider()
+	tr := New(testDefs, tp)
+
+	// the rune é of two bytes is not split
+	input := strings.Repeat("a", MaxInputSize-1) + "é" + strings.Repeat("b", 10000)
+	tr.ParseScope(context.Background(), input)
+
+	recorded := attrs(exporter.GetSpans()[0])[InputKey].AsString()
+	assert.Equal(t, strings.Repeat("a", MaxInputSize-1), recorded)
+
+	exporter.Reset()
+	input = strings.Repeat("user,", MaxInputSize/5) + "user"
+	_, err := tr.ParseScope(context.Background(), input)
+	require.NoError(t, err)
+	assert.Len(t, attrs(exporter.GetSpans()[0])[InputKey].AsString(), MaxInputSize)
+}
+
+func TestNoProvider(t *testing.T) {
+	// the global provider is a no-op until configured
+	tr := New(testDefs, nil)
+	assert.True(t, tr.Require(context.Background(), "user.edit", "user.edit"))
+}